@@ -1,18 +1,20 @@
 package sqlx
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"log"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bingoohuang/sqlparser/sqlparser"
 
 	"github.com/bingoohuang/gor"
-	"github.com/bingoohuang/strcase"
 )
 
 type Limit struct {
@@ -25,6 +27,8 @@ type Count int64
 var (
 	LimitType = reflect.TypeOf((*Limit)(nil)).Elem()
 	CountType = reflect.TypeOf((*Count)(nil)).Elem()
+
+	_contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
 )
 
 // GetDBFn is the function type to get a sql.DBGetter.
@@ -49,7 +53,7 @@ func (f StdDB) GetDB() *sql.DB { return f.db }
 var (
 	// DB is the global sql.DB for convenience.
 	DB *sql.DB
-	// SQLReplacer is the global SQLReplacer.
+	// SQLReplacer is the global SQLReplacer, which is applied after the QueryRewriters of the dao.
 	SQLReplacer QueryReplacer
 )
 
@@ -76,10 +80,15 @@ func CreateDao(dao interface{}, createDaoOpts ...CreateDaoOpter) error {
 	}
 
 	v := reflect.Indirect(daov)
-	createDBGetter(v, option)
+	if err := createDBGetter(v, option); err != nil {
+		return err
+	}
+
 	createLogger(v, option)
 	createErrorSetter(v, option)
 
+	var errs MultiError
+
 	structValue := MakeStructValue(v)
 	for i := 0; i < structValue.NumField; i++ {
 		f := structValue.FieldByIndex(i)
@@ -88,32 +97,73 @@ func CreateDao(dao interface{}, createDaoOpts ...CreateDaoOpter) error {
 			continue
 		}
 
-		tags, err := ParseTags(string(f.Tag))
-		if err != nil {
-			return err
-		}
+		if err := option.createDaoFn(f); err != nil {
+			if !option.Strict {
+				return err[0]
+			}
 
-		sqlStmt, sqlName := option.getSQLStmt(f, tags, 0)
-		if sqlStmt == nil {
-			return fmt.Errorf("failed to find sqlName %s", f.Name) // nolint:goerr113
+			errs = append(errs, err...)
 		}
+	}
 
-		parsed := &SQLParsed{
-			ID:  sqlName,
-			SQL: sqlStmt,
-			opt: option,
+	return errs.ErrorOrNil()
+}
+
+func (option *CreateDaoOpt) createDaoFn(f StructField) MultiError {
+	tags, err := ParseTags(string(f.Tag))
+	if err != nil {
+		return MultiError{err}
+	}
+
+	if name := f.GetTag("db"); name != "" {
+		if option, err = option.withNamedDB(name); err != nil {
+			return MultiError{fmt.Errorf("[%s] %w", f.Name, err)}
 		}
+	}
 
-		if err := parsed.fastParseSQL(sqlStmt.Raw()); err != nil {
-			return err
+	sqlStmt, sqlName := option.getSQLStmt(f, tags, 0)
+	if sqlStmt == nil {
+		return MultiError{fmt.Errorf("failed to find sqlName %s", f.Name)} // nolint:goerr113
+	}
+
+	parsed := &SQLParsed{
+		ID:  sqlName,
+		SQL: sqlStmt,
+		opt: option,
+	}
+
+	if err := parsed.fastParseSQL(sqlStmt.Raw()); err != nil {
+		return MultiError{err}
+	}
+
+	if parsed.script = len(splitScript(sqlStmt.Raw(), sqlDelimiter(sqlStmt))) > 1; parsed.script {
+		parsed.IsQuery = false
+	}
+
+	if option.Strict {
+		if errs := parsed.validate(f); len(errs) > 0 {
+			return errs
 		}
+	}
 
-		r := sqlRun{SQLParsed: parsed}
-		if err := r.createFn(f); err != nil {
-			return err
+	if option.StmtCacheSize > 0 {
+		parsed.stmts = newStmtCache(option.StmtCacheSize)
+	}
+
+	r := sqlRun{SQLParsed: parsed}
+	if err := r.createFn(f); err != nil {
+		return MultiError{err}
+	}
+
+	source := sqlSourceTag
+	if f.GetTag("sql") == "" {
+		if part, _ := option.DotSQL(sqlName); part != nil {
+			source = sqlSourceDotSQL
 		}
 	}
 
+	parsed.register(f, source)
+
 	return nil
 }
 
@@ -210,22 +260,55 @@ func (r *sqlRun) createFn(f StructField) error {
 }
 
 func (r *sqlRun) MakeFunc(f StructField, numIn, numOut int) func([]reflect.Value) ([]reflect.Value, error) {
-	var fn func(int, StructField, []reflect.Type, []reflect.Value) ([]reflect.Value, error)
+	var fn func(*sqlRun, int, StructField, []reflect.Type, []reflect.Value) ([]reflect.Value, error)
 
 	switch isBindByName := r.isBindBy(ByName); {
+	case r.script:
+		fn = (*sqlRun).execScript
 	case !r.IsQuery && isBindByName:
-		fn = r.execByName
+		fn = (*sqlRun).execByName
 	case !r.IsQuery && !isBindByName:
-		fn = r.execBySeq
+		fn = (*sqlRun).execBySeq
 	case r.IsQuery && isBindByName:
-		fn = r.queryByName
+		fn = (*sqlRun).queryByName
 	default: // isQuery && !isBindByName:
-		fn = r.queryBySeq
+		fn = (*sqlRun).queryBySeq
+	}
+
+	ctxArg := hasCtxArg(f.Type)
+	if ctxArg {
+		numIn--
 	}
 
 	return func(args []reflect.Value) ([]reflect.Value, error) {
-		return fn(numIn, f, makeOutTypes(f.Type, numOut), args)
+		run := r
+		if ctxArg {
+			run = r.withCtx(args[0])
+			args = args[1:]
+		}
+
+		return fn(run, numIn, f, makeOutTypes(f.Type, numOut), args)
+	}
+}
+
+// hasCtxArg tells whether the func's first argument is a context.Context,
+// which is used as the context of the call instead of the one of the option.
+func hasCtxArg(t reflect.Type) bool {
+	return t.NumIn() > 0 && t.In(0) == _contextType
+}
+
+// withCtx creates a sqlRun copy with the context of the call.
+func (r *sqlRun) withCtx(ctx reflect.Value) *sqlRun {
+	if ctx.IsNil() {
+		return r
 	}
+
+	parsed := *r.SQLParsed
+	opt := *parsed.opt
+	opt.Ctx = ctx.Interface().(context.Context)
+	parsed.opt = &opt
+
+	return &sqlRun{SQLParsed: &parsed}
 }
 
 func makeOutTypes(outType reflect.Type, numOut int) []reflect.Type {
@@ -249,12 +332,57 @@ func (p *SQLParsed) evalSeq(numIn int, f StructField, args []reflect.Value) erro
 	}
 
 	if len(args) > 0 {
-		env = p.createFieldSqlParts(env, args[0])
+		var err error
+		if env, err = p.createFieldSqlParts(env, args[0]); err != nil {
+			return err
+		}
 	}
 
 	return p.eval(numIn, f, env)
 }
 
+// namedBean creates the bean for the named bind variables.
+// The only argument is used directly, and multiple arguments are combined to a map
+// keyed by the names in the args tag, like `args:"tenant,filter"`, or _1, _2... by default,
+// so that they can be bound by the prefixed names like :tenant, :filter.status.
+func namedBean(numIn int, f StructField, args []reflect.Value) reflect.Value {
+	names := argNames(f)
+	if numIn == 0 || numIn == 1 && len(names) == 0 {
+		if numIn == 0 {
+			return reflect.Value{}
+		}
+
+		return args[0]
+	}
+
+	m := make(map[string]interface{}, numIn)
+
+	for i := 0; i < numIn; i++ {
+		if i < len(names) {
+			m[names[i]] = args[i].Interface()
+		} else {
+			m[fmt.Sprintf("_%d", i+1)] = args[i].Interface()
+		}
+	}
+
+	return reflect.ValueOf(m)
+}
+
+// argNames parses the names of the func arguments from the args tag.
+func argNames(f StructField) []string {
+	tag := f.Tag.Get("args")
+	if tag == "" {
+		return nil
+	}
+
+	names := strings.Split(tag, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+
+	return names
+}
+
 func (p *SQLParsed) eval(numIn int, f StructField, env map[string]interface{}) error {
 	runSQL, err := p.SQL.Eval(env)
 	if err != nil {
@@ -274,12 +402,7 @@ func (p *SQLParsed) eval(numIn int, f StructField, env map[string]interface{}) e
 
 func (r *sqlRun) queryByName(numIn int, f StructField,
 	outTypes []reflect.Type, args []reflect.Value) ([]reflect.Value, error) {
-	var bean reflect.Value
-
-	if numIn > 0 {
-		bean = args[0]
-	}
-
+	bean := namedBean(numIn, f, args)
 	parsed := *r.SQLParsed
 	env := parsed.createNamedMap(bean)
 
@@ -299,12 +422,16 @@ func (r *sqlRun) queryByName(numIn int, f StructField,
 		return nil, err
 	}
 
-	return parsed.wrapCounter(rows, outTypes, counterIndex, counter)
+	return parsed.wrapCounter(db, rows, outTypes, counterIndex, counter)
 }
 
-func (p *SQLParsed) wrapCounter(rows *sql.Rows, outTypes []reflect.Type, counterIndex int, counterFn func() (int64, error)) ([]reflect.Value, error) {
+func (p *SQLParsed) wrapCounter(db *sql.DB, rows *sql.Rows, outTypes []reflect.Type, counterIndex int,
+	counterFn func() (int64, error)) ([]reflect.Value, error) {
 	values, err := p.processQueryRows(rows, remove(outTypes, counterIndex))
 	_ = rows.Close()
+
+	p.logSlow(db, p.runQuery, p.runVars, p.runStart)
+
 	if err != nil || counterFn == nil {
 		return values, err
 	}
@@ -330,12 +457,7 @@ func indexOfTypes(types []reflect.Type, typ reflect.Type) int {
 // nolint:funlen
 func (r *sqlRun) execByName(numIn int, f StructField, outTypes []reflect.Type,
 	args []reflect.Value) ([]reflect.Value, error) {
-	var bean reflect.Value
-
-	if numIn > 0 {
-		bean = args[0]
-	}
-
+	bean := namedBean(numIn, f, args)
 	item0 := bean
 	itemSize := 1
 	isBeanSlice := bean.IsValid() && bean.Type().Kind() == reflect.Slice
@@ -354,6 +476,7 @@ func (r *sqlRun) execByName(numIn int, f StructField, outTypes []reflect.Type,
 		pr         *sql.Stmt
 		lastResult sql.Result
 		lastSQL    string
+		lastQuery  string
 	)
 
 	parsed := *r.SQLParsed
@@ -363,26 +486,20 @@ func (r *sqlRun) execByName(numIn int, f StructField, outTypes []reflect.Type,
 		return nil, fmt.Errorf("failed to begin tx %w", err)
 	}
 
+	defer func() { _ = tx.Rollback() }() // no-op after committed
+
 	for ii := 0; ii < itemSize; ii++ {
 		if ii > 0 {
 			item0 = bean.Index(ii)
 		}
 
-		namedMap := parsed.createNamedMap(item0)
-		if err := parsed.eval(numIn, f, namedMap); err != nil {
+		if item0, err = parsed.beforeExec(item0); err != nil {
 			return nil, err
 		}
 
-		if lastSQL != parsed.runSQL {
-			lastSQL = parsed.runSQL
-
-			query, err := r.replaceQuery(parsed.runSQL)
-			if err != nil {
-				return nil, fmt.Errorf("replaceQuery %s error %w", parsed.runSQL, err)
-			}
-			if pr, err = tx.PrepareContext(parsed.opt.Ctx, query); err != nil {
-				return nil, fmt.Errorf("failed to prepare sql %s error %w", r.RawStmt, err)
-			}
+		namedMap := parsed.createNamedMap(item0)
+		if err := parsed.eval(numIn, f, namedMap); err != nil {
+			return nil, err
 		}
 
 		vars, err := parsed.createNamedVars(item0)
@@ -390,27 +507,49 @@ func (r *sqlRun) execByName(numIn int, f StructField, outTypes []reflect.Type,
 			return nil, err
 		}
 
-		parsed.logPrepare(vars)
+		lastSQL = parsed.runSQL
 
-		lastResult, err = pr.ExecContext(parsed.opt.Ctx, vars...)
+		query, runVars, err := r.replaceQuery(parsed.runSQL, vars)
+		if err != nil {
+			return nil, fmt.Errorf("replaceQuery %s error %w", parsed.runSQL, err)
+		}
+
+		if lastQuery != query {
+			lastQuery = query
+
+			if pr, err = parsed.prepareTx(db, tx, lastQuery); err != nil {
+				return nil, fmt.Errorf("failed to prepare sql %s error %w", r.RawStmt, err)
+			}
+		}
+
+		parsed.logPrepare(query, runVars)
+
+		start := time.Now()
+		lastResult, err = parsed.auditExec(tx, vars, func() (sql.Result, error) {
+			return pr.ExecContext(parsed.opt.Ctx, runVars...)
+		})
 
 		if err != nil {
-			return nil, fmt.Errorf("failed to execute %s with vars %v error %w", parsed.runSQL, vars, err)
+			return nil, parsed.wrapDBError(parsed.runSQL, err)
 		}
+
+		parsed.logSlow(tx, parsed.runSQL, runVars, start)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commiterror %w", err)
+		return nil, parsed.wrapDBError(lastSQL, err)
 	}
 
 	return convertExecResult(lastResult, lastSQL, outTypes)
 }
 
-func (p *SQLParsed) createFieldSqlParts(m map[string]interface{}, bean reflect.Value) map[string]interface{} {
+func (p *SQLParsed) createFieldSqlParts(m map[string]interface{}, bean reflect.Value) (map[string]interface{}, error) {
 	if !bean.IsValid() || bean.Type().Kind() != reflect.Struct {
-		return m
+		return m, nil
 	}
 
+	var items []criteriaItem
+
 	structValue := MakeStructValue(bean)
 	for i, f := range structValue.FieldTypes {
 		if sqlPart := f.Tag.Get("sql"); sqlPart != "" {
@@ -420,16 +559,31 @@ func (p *SQLParsed) createFieldSqlParts(m map[string]interface{}, bean reflect.V
 
 			if f.Type.AssignableTo(LimitType) {
 				l := bean.Field(i).Interface().(Limit)
-				p.fp.AddFieldSqlPart(sqlPart,
-					[]interface{}{l.Offset, l.Length}, false)
-			} else {
-				p.fp.AddFieldSqlPart(sqlPart,
-					[]interface{}{bean.Field(i).Interface()}, true)
+				items = append(items, criteriaItem{part: sqlPart, vars: []interface{}{l.Offset, l.Length}})
+
+				continue
+			}
+
+			part, vars, err := makeCriteria(sqlPart, f.Tag.Get("op"), bean.Field(i))
+			if err != nil {
+				return nil, fmt.Errorf("field %s error %w", f.Name, err)
+			}
+
+			if err := p.encryptCriteria(f, vars); err != nil {
+				return nil, fmt.Errorf("field %s error %w", f.Name, err)
+			}
+
+			if part != "" {
+				items = append(items, criteriaItem{part: part, vars: vars, joined: true, group: f.Tag.Get("or")})
 			}
 		}
 	}
 
-	return m
+	for _, item := range mergeOrGroups(items) {
+		p.fp.AddFieldSqlPart(item.part, item.vars, item.joined)
+	}
+
+	return m, nil
 }
 
 func (p *SQLParsed) createNamedMap(bean reflect.Value) map[string]interface{} {
@@ -440,20 +594,11 @@ func (p *SQLParsed) createNamedMap(bean reflect.Value) map[string]interface{} {
 
 	switch bean.Type().Kind() {
 	case reflect.Struct:
-		structValue := MakeStructValue(bean)
-		for i, f := range structValue.FieldTypes {
-			if tagName := f.Tag.Get("name"); tagName != "" {
-				m[tagName] = bean.Field(i).Interface()
-			} else {
-				name := strcase.ToCamelLower(f.Name)
-				m[name] = bean.Field(i).Interface()
-			}
-
-		}
+		envFields(m, bean, 0)
 	case reflect.Map:
 		for _, k := range bean.MapKeys() {
 			if ks, ok := k.Interface().(string); ok {
-				m[ks] = bean.MapIndex(k).Interface()
+				m[ks] = envValue(bean.MapIndex(k), 0)
 			}
 		}
 	}
@@ -462,24 +607,9 @@ func (p *SQLParsed) createNamedMap(bean reflect.Value) map[string]interface{} {
 }
 
 func (p *SQLParsed) createNamedVars(bean reflect.Value) ([]interface{}, error) {
-	itemType := bean.Type()
-
-	var namedValueParser func(name string, item reflect.Value, itemType reflect.Type) interface{}
-
-	switch itemType.Kind() {
-	case reflect.Struct:
-		namedValueParser = func(name string, item reflect.Value, itemType reflect.Type) interface{} {
-			return item.FieldByNameFunc(func(f string) bool {
-				return matchesField2Col(itemType, f, name)
-			}).Interface()
-		}
-	case reflect.Map:
-		namedValueParser = func(name string, item reflect.Value, itemType reflect.Type) interface{} {
-			return item.MapIndex(reflect.ValueOf(name)).Interface()
-		}
-	}
-
-	if namedValueParser == nil {
+	switch itemType := bean.Type(); itemType.Kind() {
+	case reflect.Struct, reflect.Map:
+	default:
 		// nolint:goerr113
 		return nil, fmt.Errorf("named vars should use struct/map, unsupported type %v", itemType)
 	}
@@ -487,14 +617,22 @@ func (p *SQLParsed) createNamedVars(bean reflect.Value) ([]interface{}, error) {
 	vars := make([]interface{}, len(p.Vars))
 
 	for i, name := range p.Vars {
-		vars[i] = namedValueParser(name, bean, itemType)
+		v, field, err := namedValue(bean, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if vars[i], err = p.bindField(v, field); err != nil {
+			return nil, fmt.Errorf("bind var %s error %w", name, err)
+		}
 	}
 
 	return vars, nil
 }
 
-func (p *SQLParsed) logPrepare(vars interface{}) {
-	p.opt.Logger.LogStart(p.ID, p.runSQL, vars)
+// logPrepare logs the query to execute, which is rewritten and converted for the driver by replaceQuery.
+func (p *SQLParsed) logPrepare(query string, vars interface{}) {
+	p.opt.Logger.LogStart(p.ID, query, vars)
 }
 
 func (r *sqlRun) execBySeq(numIn int, f StructField,
@@ -505,20 +643,27 @@ func (r *sqlRun) execBySeq(numIn int, f StructField,
 		return nil, err
 	}
 
-	vars := parsed.makeVars(args)
-	parsed.logPrepare(vars)
+	vars, err := parsed.makeVars(args)
+	if err != nil {
+		return nil, err
+	}
 
 	db := r.opt.DBGetter.GetDB()
-	query, err := r.replaceQuery(parsed.runSQL)
+	query, runVars, err := r.replaceQuery(parsed.runSQL, vars)
 	if err != nil {
 		return nil, fmt.Errorf("replaceQuery %s error %w", parsed.runSQL, err)
 	}
 
-	result, err := db.ExecContext(parsed.opt.Ctx, query, vars...)
+	parsed.logPrepare(query, runVars)
+
+	start := time.Now()
+	result, err := parsed.execContext(db, query, runVars, vars)
 	if err != nil {
-		return nil, fmt.Errorf("execute %s error %w", r.SQL, err)
+		return nil, parsed.wrapDBError(query, err)
 	}
 
+	parsed.logSlow(db, query, runVars, start)
+
 	results, err := convertExecResult(result, query, outTypes)
 	if err != nil {
 		return nil, fmt.Errorf("execute %s error %w", r.SQL, err)
@@ -545,7 +690,7 @@ func (r *sqlRun) queryBySeq(numIn int, f StructField,
 
 	defer rows.Close()
 
-	return parsed.wrapCounter(rows, outTypes, counterIndex, counterFn)
+	return parsed.wrapCounter(db, rows, outTypes, counterIndex, counterFn)
 }
 
 func (p *SQLParsed) processQueryRows(rows *sql.Rows, outTypes []reflect.Type) ([]reflect.Value, error) {
@@ -579,7 +724,13 @@ func (p *SQLParsed) processQueryRows(rows *sql.Rows, outTypes []reflect.Type) ([
 			return nil, fmt.Errorf("scan rows %s error %w", p.SQL, err)
 		}
 
-		fillFields(mapFields, pointers)
+		if err := fillFields(mapFields, pointers); err != nil {
+			return nil, fmt.Errorf("fill fields %s error %w", p.SQL, err)
+		}
+
+		if err := p.afterFind(out[0]); err != nil {
+			return nil, err
+		}
 
 		if interceptorFn != nil {
 			outValues := make([]interface{}, len(out))
@@ -638,30 +789,38 @@ func (p *SQLParsed) getRowScanInterceptorFn() RowScanInterceptorFn {
 }
 
 func (p *SQLParsed) doQuery(db *sql.DB, args []reflect.Value, counting bool) (*sql.Rows, func() (int64, error), error) {
-	vars := p.makeVars(args)
+	vars, err := p.makeVars(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	return p.doQueryDirectVars(db, vars, counting)
 }
 
 func (p *SQLParsed) doQueryDirectVars(db *sql.DB, vars []interface{}, counting bool) (*sql.Rows, func() (int64, error), error) {
-	p.logPrepare(vars)
+	runVars := vars
 
-	query, err := p.replaceQuery(p.runSQL)
+	query, vars, err := p.replaceQuery(p.runSQL, vars)
 	if err != nil {
-		return nil, nil, fmt.Errorf("replaceQuery %s error %w", query, err)
+		return nil, nil, fmt.Errorf("replaceQuery %s error %w", p.runSQL, err)
 	}
 
-	rows, err := db.QueryContext(p.opt.Ctx, query, vars...)
+	p.logPrepare(query, vars)
+
+	p.runQuery, p.runVars, p.runStart = query, vars, time.Now()
+
+	rows, err := p.queryContext(db, query, vars)
 	if err != nil || rows.Err() != nil {
 		if err == nil {
 			err = rows.Err()
 		}
 
-		return nil, nil, fmt.Errorf("execute %s error %w", query, err)
+		return nil, nil, p.wrapDBError(query, err)
 	}
 
 	if counting {
 		return rows, func() (int64, error) {
-			count, err := p.pagingCount(db, query, vars)
+			count, err := p.pagingCount(db, runVars)
 			return count, err
 		}, nil
 	}
@@ -669,15 +828,44 @@ func (p *SQLParsed) doQueryDirectVars(db *sql.DB, vars []interface{}, counting b
 	return rows, nil, nil
 }
 
-func (p *SQLParsed) pagingCount(db *sql.DB, query string, vars []interface{}) (int64, error) {
-	parsed, err := sqlparser.Parse(query)
+func (p *SQLParsed) pagingCount(db *sql.DB, vars []interface{}) (int64, error) {
+	countQuery, vars, err := p.countQuery(vars)
 	if err != nil {
 		return 0, err
 	}
 
+	log.Printf("I! execute qury %s with args %v", countQuery, vars)
+
+	rows, err := p.queryContext(db, countQuery, vars)
+
+	return p.scanCount(rows, countQuery, err)
+}
+
+// countQuery creates the count(*) query from the runSQL before the rewriting, then rewrites it once.
+func (p *SQLParsed) countQuery(vars []interface{}) (string, []interface{}, error) {
+	countQuery, vars, err := makeCountQuery(p.runSQL, vars)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query, vars, err := p.replaceQuery(countQuery, vars)
+	if err != nil {
+		return "", nil, fmt.Errorf("replaceQuery %s error %w", countQuery, err)
+	}
+
+	return query, vars, nil
+}
+
+// makeCountQuery creates the count(*) query for the paging query, with the limit vars removed.
+func makeCountQuery(query string, vars []interface{}) (string, []interface{}, error) {
+	parsed, err := sqlparser.Parse(query)
+	if err != nil {
+		return "", nil, err
+	}
+
 	selectQuery, ok := parsed.(*sqlparser.Select)
 	if !ok {
-		return 0, errors.New("not select query")
+		return "", nil, errors.New("not select query")
 	}
 
 	selectQuery.SelectExprs = countStarExprs
@@ -688,29 +876,55 @@ func (p *SQLParsed) pagingCount(db *sql.DB, query string, vars []interface{}) (i
 
 	limitVarsCount := 0
 	if oldLimit != nil {
-		limitVarsCount++
-		if oldLimit.Offset != nil {
-			limitVarsCount++
-		}
+		limitVarsCount = countValArgs(oldLimit)
 	}
 
-	countQuery := sqlparser.String(selectQuery)
-	vars = vars[:len(vars)-limitVarsCount]
-
-	log.Printf("I! execute qury %s with args %v", countQuery, vars)
+	return sqlparser.String(selectQuery), vars[:len(vars)-limitVarsCount], nil
+}
 
-	countQuery, err = p.replaceQuery(countQuery)
+// appendLimit appends the limit of the bind variables for the row count and the offset to the select query,
+// in the syntax of limit ? offset ? which MySQL, PostgreSQL and SQLite all support.
+// nolint:goerr113
+func appendLimit(query string) (string, error) {
+	stmt, err := sqlparser.Parse(query)
 	if err != nil {
-		return 0, fmt.Errorf("replaceQuery %s error %w", countQuery, err)
+		return "", err
+	}
+
+	selectQuery, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return "", fmt.Errorf("limit requires select query, but %s", query)
 	}
 
-	rows, err := db.QueryContext(p.opt.Ctx, countQuery, vars...)
+	if selectQuery.Limit != nil {
+		return "", fmt.Errorf("query %s already has a limit", query)
+	}
+
+	selectQuery.Limit = &sqlparser.Limit{
+		Offset:   sqlparser.NewValArg([]byte("?")),
+		Rowcount: sqlparser.NewValArg([]byte("?")),
+	}
+
+	buf := sqlparser.NewTrackedBuffer(func(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+		if limit, ok := node.(*sqlparser.Limit); ok && limit != nil {
+			buf.Myprintf(" limit %v offset %v", limit.Rowcount, limit.Offset)
+			return
+		}
+
+		node.Format(buf)
+	})
+	buf.Myprintf("%v", selectQuery)
+
+	return buf.String(), nil
+}
+
+func (p *SQLParsed) scanCount(rows *sql.Rows, countQuery string, err error) (int64, error) {
 	if err != nil || rows.Err() != nil {
 		if err == nil {
 			err = rows.Err()
 		}
 
-		return 0, fmt.Errorf("execute %s error %w", countQuery, err)
+		return 0, p.wrapDBError(countQuery, err)
 	}
 
 	defer rows.Close()
@@ -722,7 +936,6 @@ func (p *SQLParsed) pagingCount(db *sql.DB, query string, vars []interface{}) (i
 	}
 
 	return count, nil
-
 }
 
 func (p *SQLParsed) createMapFields(columns []string, out0Type reflect.Type,
@@ -785,34 +998,41 @@ func (p *SQLParsed) makeMapField(col string, outType reflect.Type) selectItem {
 }
 
 func (p *SQLParsed) makeStructField(col string, outType reflect.Type) selectItem {
-	fv, ok := outType.FieldByNameFunc(func(field string) bool {
-		return matchesField2Col(outType, field, col)
-	})
+	if fv, index, ok := findStructField(outType, col, 0); ok {
+		item := &structItem{StructField: &fv, index: index, json: isJSONField(fv)}
+		if item.encrypt = parseEncryptTag(fv); item.encrypt != nil {
+			item.keys = p.opt.KeyProvider
+		}
 
-	if ok {
-		return &structItem{StructField: &fv}
+		return item
 	}
 
 	return nil
 }
 
-func (p *SQLParsed) makeVars(args []reflect.Value) []interface{} {
+func (p *SQLParsed) makeVars(args []reflect.Value) ([]interface{}, error) {
 	vars := make([]interface{}, 0, len(p.Vars))
 
 	for i, name := range p.Vars[:len(p.Vars)-len(p.fp.fieldVars)] {
-		if p.BindBy == ByAuto {
-			vars = append(vars, args[i].Interface())
-		} else {
+		arg := args[i]
+		if p.BindBy != ByAuto {
 			seq, _ := strconv.Atoi(name)
-			vars = append(vars, args[seq-1].Interface())
+			arg = args[seq-1]
+		}
+
+		v, err := bindValue(arg, false)
+		if err != nil {
+			return nil, fmt.Errorf("bind var %s error %w", name, err)
 		}
+
+		vars = append(vars, v)
 	}
 
 	if len(p.fp.fieldVars) > 0 {
 		vars = append(vars, p.fp.fieldVars...)
 	}
 
-	return vars
+	return vars, nil
 }
 
 func (p *SQLParsed) logError(err error) {
@@ -851,20 +1071,39 @@ func convertExecResult(result sql.Result, query string, outTypes []reflect.Type)
 
 type selectItem interface {
 	Type() reflect.Type
-	Set(val reflect.Value)
+	// Set sets the scanned value, null tells whether the scanned column is NULL.
+	Set(val reflect.Value, null bool) error
 	ResetParent(parent reflect.Value)
 }
 
 type structItem struct {
 	*reflect.StructField
-	parent reflect.Value
+	index   []int
+	json    bool
+	encrypt *encryptTag
+	keys    KeyProvider
+	parent  reflect.Value
 }
 
 func (s *structItem) Type() reflect.Type               { return s.StructField.Type }
 func (s *structItem) ResetParent(parent reflect.Value) { s.parent = parent }
-func (s *structItem) Set(val reflect.Value) {
-	f := s.parent.FieldByName(s.StructField.Name)
+func (s *structItem) Set(val reflect.Value, null bool) error {
+	f, err := fieldByIndexAlloc(s.parent, s.index, null)
+	if err != nil || !f.IsValid() {
+		return err
+	}
+
+	if s.encrypt != nil {
+		return s.decryptField(f, val.Bytes())
+	}
+
+	if s.json {
+		return unmarshalJSONField(f, val.Bytes())
+	}
+
 	f.Set(val.Convert(f.Type()))
+
+	return nil
 }
 
 type mapItem struct {
@@ -875,7 +1114,10 @@ type mapItem struct {
 
 func (s *mapItem) Type() reflect.Type               { return s.vType }
 func (s *mapItem) ResetParent(parent reflect.Value) { s.parent = parent }
-func (s *mapItem) Set(val reflect.Value)            { s.parent.SetMapIndex(s.k, val) }
+func (s *mapItem) Set(val reflect.Value, _ bool) error {
+	s.parent.SetMapIndex(s.k, val)
+	return nil
+}
 
 type singleValue struct {
 	ptr    bool
@@ -885,12 +1127,14 @@ type singleValue struct {
 
 func (s *singleValue) Type() reflect.Type               { return s.vType }
 func (s *singleValue) ResetParent(parent reflect.Value) { s.parent = parent }
-func (s *singleValue) Set(val reflect.Value) {
+func (s *singleValue) Set(val reflect.Value, _ bool) error {
 	if !s.parent.IsValid() {
 		s.parent = reflect.Indirect(reflect.New(s.vType))
 	}
 
 	s.parent.Set(val)
+
+	return nil
 }
 
 func resetDests(out0Type reflect.Type, out0TypePtr bool,
@@ -938,7 +1182,9 @@ func resetDests(out0Type reflect.Type, out0TypePtr bool,
 			fv.ResetParent(out[i])
 		}
 
-		if ImplSQLScanner(fv.Type()) {
+		if si, ok := fv.(*structItem); ok && (si.json || si.encrypt != nil) {
+			pointers[i] = new([]byte)
+		} else if ImplSQLScanner(fv.Type()) {
 			pointers[i] = reflect.New(fv.Type()).Interface()
 		} else {
 			pointers[i] = &NullAny{Type: fv.Type()}
@@ -948,16 +1194,41 @@ func resetDests(out0Type reflect.Type, out0TypePtr bool,
 	return pointers, out
 }
 
-func fillFields(mapFields []selectItem, pointers []interface{}) {
+// scannedNull tells whether the scan destination got a NULL.
+func scannedNull(pointer interface{}) bool {
+	switch p := pointer.(type) {
+	case *NullAny:
+		return !p.Val.IsValid()
+	case *[]byte:
+		return *p == nil
+	case driver.Valuer:
+		v, err := p.Value()
+		return err == nil && v == nil
+	default:
+		return false
+	}
+}
+
+func fillFields(mapFields []selectItem, pointers []interface{}) error {
 	for i, field := range mapFields {
 		if field == nil {
 			continue
 		}
 
+		var err error
+
+		null := scannedNull(pointers[i])
+
 		if p, ok := pointers[i].(*NullAny); ok {
-			field.Set(p.getVal())
+			err = field.Set(p.getVal(), null)
 		} else {
-			field.Set(reflect.ValueOf(pointers[i]).Elem())
+			err = field.Set(reflect.ValueOf(pointers[i]).Elem(), null)
+		}
+
+		if err != nil {
+			return err
 		}
 	}
+
+	return nil
 }
@@ -0,0 +1,343 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Querier is the common interface of *sql.DB, *sql.Tx and *sql.Conn for the ad-hoc query API.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Select executes the query and scans the rows into dest by the same way of the dao funcs,
+// the dest can be a pointer to any type the dao funcs return, like *[]Person, *Person,
+// *map[string]string, *[]string or *int.
+// The args are the bind variables for :1, :2 or ?, or the struct/map bean for the named bind variables.
+// A Limit arg appends the limit ? offset ? to the select query without a limit,
+// a *Count arg receives the total count ignoring the limit,
+// and the CreateDaoOpter args like WithLimit or WithKeyProvider are applied.
+// The ? bind marks are converted for the driver of the *sql.DB, or the WithDB arg for the *sql.Tx and *sql.Conn.
+func Select(ctx context.Context, q Querier, dest interface{}, query string, args ...interface{}) error {
+	part, err := adhocPart(query)
+	if err != nil {
+		return err
+	}
+
+	return adhocQuery(ctx, q, dest, part, args)
+}
+
+// Get executes the query and scans the first row into dest like Select,
+// returns sql.ErrNoRows when no rows found.
+func Get(ctx context.Context, q Querier, dest interface{}, query string, args ...interface{}) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("dest should be a non-nil pointer, but %T", dest) // nolint:goerr113
+	}
+
+	if dv.Elem().Kind() == reflect.Slice {
+		return Select(ctx, q, dest, query, args...)
+	}
+
+	part, err := adhocPart(query)
+	if err != nil {
+		return err
+	}
+
+	rows := reflect.New(reflect.SliceOf(dv.Elem().Type()))
+	if err := adhocQuery(ctx, q, rows.Interface(), part, append(args, WithLimit(1))); err != nil {
+		return err
+	}
+
+	if rows.Elem().Len() == 0 {
+		return sql.ErrNoRows
+	}
+
+	dv.Elem().Set(rows.Elem().Index(0))
+
+	return nil
+}
+
+// NamedExec executes the query with the named bind variables from the bean, which can be
+// a struct, a map, or a slice of them to execute for each item, and returns the last result.
+// The BeforeInsert and BeforeUpdate hooks of the beans are called like the dao exec.
+func NamedExec(ctx context.Context, q Querier, query string, bean interface{},
+	opts ...CreateDaoOpter) (sql.Result, error) {
+	part, err := adhocPart(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := newAdhocParsed(ctx, q, part, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isBindBy(ByName, ByNone) {
+		return nil, fmt.Errorf("sql %s required named varialbes, but %v", query, p.BindBy) // nolint:goerr113
+	}
+
+	items := []reflect.Value{reflect.ValueOf(bean)}
+	if bv := reflect.Indirect(items[0]); bv.Kind() == reflect.Slice {
+		items = make([]reflect.Value, bv.Len())
+		for i := range items {
+			items[i] = bv.Index(i)
+		}
+	}
+
+	if p.opt.audit == nil || !p.opt.audit.mayAudit(p.RawStmt) {
+		return p.namedExecItems(q, items)
+	}
+
+	// the before images, the exec, the after images and the sink run in one transaction.
+	tx, done, err := auditTx(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.namedExecItems(tx, items)
+
+	return result, done(err)
+}
+
+func (p *SQLParsed) namedExecItems(q Querier, items []reflect.Value) (sql.Result, error) {
+	var result sql.Result
+
+	for _, item := range items {
+		parsed := *p
+
+		var beans []interface{}
+		if parsed.isBindBy(ByName) {
+			bean, err := parsed.beforeExec(item)
+			if err != nil {
+				return nil, err
+			}
+
+			beans = []interface{}{bean.Interface()}
+		}
+
+		vars, err := parsed.adhocVars(beans)
+		if err != nil {
+			return nil, err
+		}
+
+		runQuery, runVars, err := parsed.replaceQuery(parsed.runSQL, vars)
+		if err != nil {
+			return nil, fmt.Errorf("replaceQuery %s error %w", parsed.runSQL, err)
+		}
+
+		parsed.logPrepare(runQuery, runVars)
+
+		result, err = parsed.auditExec(q, vars, func() (sql.Result, error) {
+			return q.ExecContext(parsed.opt.Ctx, runQuery, runVars...)
+		})
+		if err != nil {
+			return nil, parsed.wrapDBError(runQuery, err)
+		}
+	}
+
+	return result, nil
+}
+
+func adhocPart(query string) (SQLPart, error) {
+	return DotSQLItem{Name: "adhoc", Content: []string{query}}.DynamicSQL()
+}
+
+func newAdhocParsed(ctx context.Context, q Querier, part SQLPart, opts []CreateDaoOpter) (*SQLParsed, error) {
+	opt, err := applyCreateDaoOption(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	opt.Ctx = ctx
+
+	if opt.Logger == nil {
+		opt.Logger = &DaoLoggerNoop{}
+	}
+
+	if db, ok := q.(*sql.DB); ok && opt.DBGetter == nil {
+		opt.DBGetter = MakeDB(db)
+	}
+
+	p := &SQLParsed{ID: "adhoc", SQL: part, opt: opt}
+	if err := p.fastParseSQL(part.Raw()); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// adhocArgs is the args of the ad-hoc query separated by their usages.
+type adhocArgs struct {
+	vars  []interface{}
+	limit *Limit
+	count *Count
+	opts  []CreateDaoOpter
+}
+
+func splitAdhocArgs(args []interface{}) adhocArgs {
+	var a adhocArgs
+
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case Limit:
+			a.limit = &v
+		case *Limit:
+			a.limit = v
+		case *Count:
+			a.count = v
+		case CreateDaoOpter:
+			a.opts = append(a.opts, v)
+		default:
+			a.vars = append(a.vars, arg)
+		}
+	}
+
+	return a
+}
+
+// adhocQuery executes the query of the SQLPart and scans the rows into dest.
+func adhocQuery(ctx context.Context, q Querier, dest interface{}, part SQLPart, args []interface{}) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("dest should be a non-nil pointer, but %T", dest) // nolint:goerr113
+	}
+
+	a := splitAdhocArgs(args)
+
+	p, err := newAdhocParsed(ctx, q, part, a.opts)
+	if err != nil {
+		return err
+	}
+
+	vars, err := p.adhocVars(a.vars)
+	if err != nil {
+		return err
+	}
+
+	if a.limit != nil {
+		if p.runSQL, err = appendLimit(p.runSQL); err != nil {
+			return err
+		}
+
+		vars = append(vars, a.limit.Length, a.limit.Offset)
+	}
+
+	query, runVars, err := p.replaceQuery(p.runSQL, vars)
+	if err != nil {
+		return fmt.Errorf("replaceQuery %s error %w", p.runSQL, err)
+	}
+
+	p.logPrepare(query, runVars)
+
+	rows, err := q.QueryContext(ctx, query, runVars...)
+	if err != nil {
+		return p.wrapDBError(query, err)
+	}
+
+	values, err := p.processQueryRows(rows, []reflect.Type{dv.Elem().Type()})
+	_ = rows.Close()
+
+	if err != nil {
+		return err
+	}
+
+	dv.Elem().Set(values[0])
+
+	if a.count == nil {
+		return nil
+	}
+
+	countQuery, countVars, err := p.countQuery(vars)
+	if err != nil {
+		return err
+	}
+
+	rows, err = q.QueryContext(ctx, countQuery, countVars...)
+	count, err := p.scanCount(rows, countQuery, err)
+	*a.count = Count(count)
+
+	return err
+}
+
+// adhocVars evaluates the dynamic SQL and creates the bind variables from the args.
+func (p *SQLParsed) adhocVars(args []interface{}) ([]interface{}, error) {
+	if p.isBindBy(ByName) {
+		bean, err := adhocBean(args)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.adhocEval(p.createNamedMap(bean)); err != nil {
+			return nil, err
+		}
+
+		return p.createNamedVars(bean)
+	}
+
+	values := make([]reflect.Value, len(args))
+	env := make(map[string]interface{})
+
+	for i, arg := range args {
+		values[i] = reflect.ValueOf(arg)
+		env[fmt.Sprintf("_%d", i+1)] = arg
+	}
+
+	if err := p.adhocEval(env); err != nil {
+		return nil, err
+	}
+
+	if p.isBindBy(ByNone) { // the raw ? placeholders.
+		vars := make([]interface{}, len(values))
+		for i, v := range values {
+			var err error
+			if vars[i], err = bindValue(v, false); err != nil {
+				return nil, err
+			}
+		}
+
+		return vars, nil
+	}
+
+	if n := len(p.Vars); p.BindBy == ByAuto && len(args) < n || p.BindBy == BySeq && len(args) < p.MaxSeq {
+		// nolint:goerr113
+		return nil, fmt.Errorf("sql %s required max %d vars, but only %d args", p.RawStmt, max(n, p.MaxSeq), len(args))
+	}
+
+	return p.makeVars(values)
+}
+
+func (p *SQLParsed) adhocEval(env map[string]interface{}) error {
+	runSQL, err := p.SQL.Eval(env)
+	if err != nil {
+		return err
+	}
+
+	return p.parseSQL(runSQL)
+}
+
+// adhocBean creates the bean for the named bind variables, the only arg is used directly,
+// and multiple args are combined to a map keyed by _1, _2...
+func adhocBean(args []interface{}) (reflect.Value, error) {
+	switch len(args) {
+	case 0:
+		return reflect.Value{}, fmt.Errorf("named bind variables required a bean arg") // nolint:goerr113
+	case 1:
+		bean := reflect.Indirect(reflect.ValueOf(args[0]))
+		if !bean.IsValid() {
+			return bean, fmt.Errorf("named bind variables required a non-nil bean arg") // nolint:goerr113
+		}
+
+		return bean, nil
+	}
+
+	m := make(map[string]interface{}, len(args))
+	for i, arg := range args {
+		m[fmt.Sprintf("_%d", i+1)] = arg
+	}
+
+	return reflect.ValueOf(m), nil
+}
@@ -0,0 +1,161 @@
+package sqlx_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/bingoohuang/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdhoc(t *testing.T) {
+	that := assert.New(t)
+	ctx := context.Background()
+	db := openDB(t)
+
+	_, err := db.Exec("create table person(id varchar(100), age int)")
+	that.Nil(err)
+
+	for _, p := range []person{{ID: "10", Age: 10}, {ID: "20", Age: 20}, {ID: "30", Age: 30}} {
+		_, err := sqlx.NamedExec(ctx, db, "insert into person(id, age) values(:id, :age)", p)
+		that.Nil(err)
+	}
+
+	_, err = sqlx.NamedExec(ctx, db, "insert into person(id, age) values(:id, :age)",
+		[]map[string]interface{}{{"id": "40", "age": 40}, {"id": "50", "age": 50}})
+	that.Nil(err)
+
+	var persons []person
+	that.Nil(sqlx.Select(ctx, db, &persons, "select id, age from person where age >= :1 order by id", 40))
+	that.Equal([]person{{ID: "40", Age: 40}, {ID: "50", Age: 50}}, persons)
+
+	var count sqlx.Count
+	that.Nil(sqlx.Select(ctx, db, &persons, "select id, age from person order by id", sqlx.Limit{Offset: 1, Length: 2}, &count))
+	that.Equal([]person{{ID: "20", Age: 20}, {ID: "30", Age: 30}}, persons)
+	that.Equal(sqlx.Count(5), count)
+
+	var ids []string
+	that.Nil(sqlx.Select(ctx, db, &ids, "select id from person where age < ? order by id", 30))
+	that.Equal([]string{"10", "20"}, ids)
+
+	var m map[string]string
+	that.Nil(sqlx.Select(ctx, db, &m, "select id, age from person where id=:id", person{ID: "10"}))
+	that.Equal(map[string]string{"id": "10", "age": "10"}, m)
+
+	tx, err := db.Begin()
+	that.Nil(err)
+
+	var p person
+	that.Nil(sqlx.Get(ctx, tx, &p, "select id, age from person where 1=1 /* if _1 > 0 */ and age > :1 /* end */ order by age", 20))
+	that.Equal(person{ID: "30", Age: 30}, p)
+	that.Equal(sql.ErrNoRows, sqlx.Get(ctx, tx, &p, "select id, age from person where age > :1", 100))
+
+	count = 0
+	that.Nil(sqlx.Select(ctx, tx, &persons, "select id, age from person where age > :1 order by id", 10,
+		sqlx.Limit{Offset: 2, Length: 2}, &count, sqlx.WithDB(db)))
+	that.Equal([]person{{ID: "40", Age: 40}, {ID: "50", Age: 50}}, persons)
+	that.Equal(sqlx.Count(4), count)
+	that.Error(sqlx.Select(ctx, tx, &persons, "select id, age from person limit 1", sqlx.Limit{Length: 2}))
+	that.Nil(tx.Rollback())
+
+	conn, err := db.Conn(ctx)
+	that.Nil(err)
+
+	var age int
+	that.Nil(sqlx.Get(ctx, conn, &age, "select age from person where id=:1", "50"))
+	that.Equal(50, age)
+	that.Nil(conn.Close())
+}
+
+func TestAdhocLimitSQL(t *testing.T) {
+	that := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	that.Nil(err)
+
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("select id, age from person where age > ? order by id asc limit ? offset ?")).
+		WithArgs(10, 20, 40).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("50", 50))
+
+	var persons []person
+	that.Nil(sqlx.Select(context.Background(), db, &persons, "select id, age from person where age > :1 order by id", 10,
+		sqlx.Limit{Offset: 40, Length: 20}))
+	that.Equal([]person{{ID: "50", Age: 50}}, persons)
+	that.Nil(mock.ExpectationsWereMet())
+}
+
+// postgresDriver is the sqlite3 driver registered as postgres to test the $n bind marks,
+// which sqlite3 accepts too.
+type postgresDriver struct{ sqlite3.SQLiteDriver }
+
+func init() { // nolint:gochecknoinits
+	sql.Register("postgres", &postgresDriver{})
+}
+
+type startLogger struct {
+	sqlx.DaoLoggerNoop
+	sqls []string
+}
+
+func (l *startLogger) LogStart(_, sql string, _ interface{}) { l.sqls = append(l.sqls, sql) }
+
+type bindMarksDao struct {
+	CreateTable func()              `sql:"create table person(id varchar(100), age int)"`
+	Add         func(person)        `sql:"insert into person(id, age) values(:id, :age)"`
+	Find        func(string) person `sql:"select id, age from person where id = :1"`
+}
+
+func TestBindMarksConverted(t *testing.T) {
+	that := assert.New(t)
+
+	db, err := sql.Open("postgres", ":memory:")
+	that.Nil(err)
+
+	db.SetMaxOpenConns(1)
+
+	logger := &startLogger{}
+	dao := &bindMarksDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(db), sqlx.WithLogger(logger)))
+
+	// the bind marks are converted for the driver right before the execution, in the dao and ad-hoc calls.
+	dao.CreateTable()
+	dao.Add(person{ID: "10", Age: 10})
+	that.Equal(person{ID: "10", Age: 10}, dao.Find("10"))
+	that.Equal([]string{"create table person(id varchar(100), age int)",
+		"insert into person(id, age) values($1, $2)", "select id, age from person where id = $1"}, logger.sqls)
+
+	var ps []person
+
+	that.Nil(sqlx.Select(context.Background(), db, &ps, "select id, age from person where age >= :1 order by id",
+		10, sqlx.Limit{Length: 10}, sqlx.WithLogger(logger)))
+	that.Equal([]person{{ID: "10", Age: 10}}, ps)
+	that.Equal("select id, age from person where age >= $1 order by id asc limit $2 offset $3", logger.sqls[3])
+}
+
+func TestAdhocHooks(t *testing.T) {
+	that := assert.New(t)
+	ctx := context.Background()
+	db := openDB(t)
+
+	_, err := db.Exec("create table person(id varchar(100), age int)")
+	that.Nil(err)
+
+	// the hooks are called like the dao exec.
+	_, err = sqlx.NamedExec(ctx, db, "insert into person(id, age) values(:id, :age)", hookPerson{ID: "ab", Age: 10})
+	that.Nil(err)
+	_, err = sqlx.NamedExec(ctx, db, "update person set age = :age where id = :id", hookPerson{ID: "AB", Age: 10})
+	that.Nil(err)
+	_, err = sqlx.NamedExec(ctx, db, "insert into person(id, age) values(:id, :age)", hookPerson{ID: "cd", Age: -1})
+	that.Error(err)
+
+	var ps []person
+
+	that.Nil(sqlx.Select(ctx, db, &ps, "select id, age from person"))
+	that.Equal([]person{{ID: "AB", Age: 11}}, ps)
+}
@@ -0,0 +1,44 @@
+package sqlx_test
+
+import (
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type argsFilter struct {
+	MinAge int
+	Status string
+}
+
+type argsDao struct {
+	CreateTable func()                           `sql:"create table person(id varchar(100), age int, tenant int)"`
+	Add         func(tenantID int64, p person)   `sql:"insert into person(id, age, tenant) values(:p.id, :p.age, :tenant)" args:"tenant,p"`
+	Find        func(int64, argsFilter) []person `sql:"select id, age from person where tenant=:_1 /* if _2.minAge > 0 */ and age >= :_2.minAge /* end */ order by id"`
+	Count       func(int64, string) int          `sql:"select count(*) from person where tenant=:tenant and id like :id" args:"tenant,id"`
+	BadArgs     func(int64, string) int          `sql:"select count(*) from person where tenant=:tenant" args:"tenant"`
+
+	Err error
+}
+
+func TestMultipleNamedArgs(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &argsDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t))))
+
+	dao.CreateTable()
+	dao.Add(1, person{ID: "10", Age: 10})
+	dao.Add(1, person{ID: "20", Age: 20})
+	dao.Add(2, person{ID: "30", Age: 30})
+
+	that.Equal([]person{{ID: "10", Age: 10}, {ID: "20", Age: 20}}, dao.Find(1, argsFilter{}))
+	that.Equal([]person{{ID: "20", Age: 20}}, dao.Find(1, argsFilter{MinAge: 15}))
+	that.Equal(1, dao.Count(2, "3%"))
+	that.Nil(dao.Err)
+
+	that.Equal(0, dao.BadArgs(2, "3%"))
+	that.Error(dao.Err)
+	that.Contains(dao.Err.Error(), "has 1 names")
+}
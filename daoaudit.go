@@ -0,0 +1,469 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bingoohuang/sqlparser/sqlparser"
+)
+
+// AuditRecord is the audit record of the rows changed by an UPDATE or DELETE.
+type AuditRecord struct {
+	DaoID  string                   `json:"daoId"`
+	Table  string                   `json:"table"`
+	Action string                   `json:"action"`
+	Actor  string                   `json:"actor,omitempty"`
+	SQL    string                   `json:"sql"`
+	Args   []interface{}            `json:"args,omitempty"`
+	Time   time.Time                `json:"time"`
+	Before []map[string]interface{} `json:"before"`
+	After  []map[string]interface{} `json:"after,omitempty"`
+}
+
+// AuditSink receives the audit records.
+// The q is the transaction of the exec, so that the sink can write the records atomically with the changes.
+type AuditSink interface {
+	Audit(ctx context.Context, q Querier, record AuditRecord) error
+}
+
+// AuditSinkFn is the func prototype of AuditSink, which can be used as a callback sink.
+type AuditSinkFn func(ctx context.Context, q Querier, record AuditRecord) error
+
+// Audit receives the audit record.
+func (f AuditSinkFn) Audit(ctx context.Context, q Querier, record AuditRecord) error {
+	return f(ctx, q, record)
+}
+
+// AuditTableSink writes the audit records to the table in the same transaction of the exec.
+// The table requires the columns dao_id, table_name, action, actor, sql_text, args,
+// before_image, after_image and created, the args and the images are written in JSON.
+type AuditTableSink struct {
+	Table string
+}
+
+// Audit inserts the audit record to the table.
+func (s AuditTableSink) Audit(ctx context.Context, q Querier, r AuditRecord) error {
+	args, _ := json.Marshal(r.Args)
+	before, _ := json.Marshal(r.Before)
+	after, _ := json.Marshal(r.After)
+
+	_, err := q.ExecContext(ctx, "insert into "+s.Table+"(dao_id, table_name, action, actor, sql_text, args, "+
+		"before_image, after_image, created) values(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		r.DaoID, r.Table, r.Action, r.Actor, r.SQL, string(args), string(before), string(after), r.Time)
+	if err != nil {
+		return fmt.Errorf("failed to write audit to table %s error %w", s.Table, err)
+	}
+
+	return nil
+}
+
+// AuditFileSink appends the audit records to the file in JSON lines.
+type AuditFileSink struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+// NewAuditFileSink creates the AuditFileSink which appends to the file.
+func NewAuditFileSink(file string) (*AuditFileSink, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file %s error %w", file, err)
+	}
+
+	return &AuditFileSink{file: f}, nil
+}
+
+// Audit appends the audit record to the file.
+func (s *AuditFileSink) Audit(_ context.Context, _ Querier, r AuditRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record error %w", err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit file %s error %w", s.file.Name(), err)
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (s *AuditFileSink) Close() error { return s.file.Close() }
+
+type actorKey struct{}
+
+// WithActor returns a copy of the ctx which carries the actor for the audit records.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor carried by the ctx.
+func Actor(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	return actor
+}
+
+type auditOpt struct {
+	sink AuditSink
+	keys map[string]string // table name -> key column
+}
+
+// WithAudit specifies the AuditSink and the auditable tables.
+// The table can be followed by its key column like person:pid, and the key column is id by default.
+// The rows affected by the UPDATE or DELETE of the auditable tables are selected before the exec
+// in the same transaction, and the after images of the UPDATE are selected by the keys of the before images,
+// or by the new key when the UPDATE sets the key column to a bind variable or a literal.
+func WithAudit(sink AuditSink, tables ...string) CreateDaoOpter {
+	a := &auditOpt{sink: sink, keys: make(map[string]string, len(tables))}
+
+	for _, table := range tables {
+		name, key := table, "id"
+		if p := strings.Index(table, ":"); p > 0 {
+			name, key = table[:p], table[p+1:]
+		}
+
+		a.keys[strings.ToLower(name)] = key
+	}
+
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.audit = a })
+}
+
+type auditTarget struct {
+	table, key, action string
+	selectSQL          string
+	selectVars         []interface{}
+
+	newKey interface{} // the key set by the UPDATE when keySet
+	keySet bool
+}
+
+// mayAudit tells whether the query may be the UPDATE or DELETE of the auditable tables without parsing it,
+// by its first word and the names of the auditable tables in it.
+func (a *auditOpt) mayAudit(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+
+	if verb := strings.ToLower(fields[0]); verb != "update" && verb != "delete" {
+		return false
+	}
+
+	query = strings.ToLower(query)
+
+	for table := range a.keys {
+		if strings.Contains(query, table) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// target derives the select of the rows affected by the UPDATE or DELETE of the auditable table,
+// nil for the others.
+// nolint:goerr113
+func (a *auditOpt) target(query string, vars []interface{}) (*auditTarget, error) {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sql %s for audit error %w", query, err)
+	}
+
+	var (
+		t       auditTarget
+		from    sqlparser.TableExprs
+		set     sqlparser.UpdateExprs
+		setVars []interface{}
+		sel     = &sqlparser.Select{SelectExprs: sqlparser.SelectExprs{&sqlparser.StarExpr{}}}
+	)
+
+	switch s := stmt.(type) {
+	case *sqlparser.Update:
+		t.action, from = "update", s.TableExprs
+		set, setVars = s.Exprs, vars
+		sel.Where, sel.OrderBy, sel.Limit = s.Where, s.OrderBy, s.Limit
+		if n := countValArgs(s.Exprs); n <= len(vars) {
+			vars = vars[n:]
+		}
+	case *sqlparser.Delete:
+		t.action, from = "delete", s.TableExprs
+		sel.Where, sel.OrderBy, sel.Limit = s.Where, s.OrderBy, s.Limit
+	default:
+		return nil, nil
+	}
+
+	if !a.auditable(from) {
+		return nil, nil
+	}
+
+	var name sqlparser.TableName
+	if aliased, ok := from[0].(*sqlparser.AliasedTableExpr); ok && len(from) == 1 {
+		name, ok = aliased.Expr.(sqlparser.TableName)
+		if !ok {
+			return nil, nil
+		}
+	} else {
+		return nil, fmt.Errorf("multiple tables %s with auditable tables are not supported", sqlparser.String(from))
+	}
+
+	t.key = a.keys[strings.ToLower(name.Name.String())]
+
+	for i, e := range set {
+		if !e.Name.Name.EqualString(t.key) {
+			continue
+		}
+
+		if t.newKey, t.keySet = setValue(e.Expr, setVars, countValArgs(set[:i])); !t.keySet {
+			return nil, fmt.Errorf("update of the key column %s of the auditable table %s to %s is not supported",
+				t.key, sqlparser.String(name), sqlparser.String(e.Expr))
+		}
+	}
+
+	sel.From = from
+	t.table = sqlparser.String(name)
+	t.selectSQL = sqlparser.String(sel)
+	t.selectVars = vars
+
+	return &t, nil
+}
+
+// setValue returns the value of the SET expr, which is the bind variable at the index of the vars or a literal.
+func setValue(expr sqlparser.Expr, vars []interface{}, index int) (interface{}, bool) {
+	v, ok := expr.(*sqlparser.SQLVal)
+	if !ok {
+		return nil, false
+	}
+
+	switch v.Type {
+	case sqlparser.ValArg:
+		if index < len(vars) {
+			return vars[index], true
+		}
+	case sqlparser.StrVal, sqlparser.IntVal, sqlparser.FloatVal:
+		return string(v.Val), true
+	}
+
+	return nil, false
+}
+
+// auditable tells whether the table exprs touch the auditable tables.
+func (a *auditOpt) auditable(exprs sqlparser.TableExprs) bool {
+	found := false
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if e, ok := node.(*sqlparser.AliasedTableExpr); ok {
+			if name, ok := e.Expr.(sqlparser.TableName); ok {
+				if _, ok := a.keys[strings.ToLower(name.Name.String())]; ok {
+					found = true
+				}
+			}
+		}
+
+		return !found, nil
+	}, exprs)
+
+	return found
+}
+
+func countValArgs(node sqlparser.SQLNode) int {
+	n := 0
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if v, ok := node.(*sqlparser.SQLVal); ok && v.Type == sqlparser.ValArg {
+			n++
+		}
+
+		return true, nil
+	}, node)
+
+	return n
+}
+
+// auditExec executes the exec with the audit of the changed rows when the runSQL is auditable.
+// The vars are the ones of the runSQL before the rewriting, and q should be the transaction of the exec.
+func (p *SQLParsed) auditExec(q Querier, vars []interface{}, exec func() (sql.Result, error)) (sql.Result, error) {
+	a := p.opt.audit
+	if a == nil || !a.mayAudit(p.runSQL) {
+		return exec()
+	}
+
+	t, err := a.target(p.runSQL, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return exec()
+	}
+
+	ctx := p.opt.Ctx
+	before, err := p.auditRows(q, t.selectSQL, t.selectVars)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := exec()
+	if err != nil {
+		return nil, err
+	}
+
+	record := AuditRecord{DaoID: p.ID, Table: t.table, Action: t.action, Actor: Actor(ctx),
+		SQL: p.runSQL, Args: vars, Time: time.Now(), Before: before}
+
+	if t.action == "update" && len(before) > 0 {
+		if record.After, err = p.auditAfter(q, t, before); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.sink.Audit(ctx, q, record); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// execAudited executes the query with its runVars in a transaction with the audit,
+// the vars are the ones before the rewriting.
+func (p *SQLParsed) execAudited(db *sql.DB, query string, runVars, vars []interface{}) (sql.Result, error) {
+	tx, done, err := auditTx(p.opt.Ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.auditExec(tx, vars, func() (sql.Result, error) {
+		return tx.ExecContext(p.opt.Ctx, query, runVars...)
+	})
+
+	return result, done(err)
+}
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// auditTx begins a transaction on the *sql.DB or *sql.Conn for the audit, or uses the *sql.Tx as it is.
+// The returned done commits the begun transaction when err is nil, or rolls it back.
+// nolint:goerr113
+func auditTx(ctx context.Context, q Querier) (Querier, func(err error) error, error) {
+	if _, ok := q.(*sql.Tx); ok {
+		return q, func(err error) error { return err }, nil
+	}
+
+	b, ok := q.(txBeginner)
+	if !ok {
+		return nil, nil, fmt.Errorf("audit requires a transaction, but %T", q)
+	}
+
+	tx, err := b.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin tx %w", err)
+	}
+
+	return tx, func(err error) error {
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		return tx.Commit()
+	}, nil
+}
+
+// nolint:goerr113
+func (p *SQLParsed) auditAfter(q Querier, t *auditTarget,
+	before []map[string]interface{}) ([]map[string]interface{}, error) {
+	keys := make([]interface{}, 0, len(before))
+
+	if t.keySet { // all the rows updated have the new key.
+		keys = append(keys, t.newKey)
+	} else {
+		for _, row := range before {
+			key, ok := findAuditKey(row, t.key)
+			if !ok {
+				return nil, fmt.Errorf("key column %s not found in the auditable table %s", t.key, t.table)
+			}
+
+			keys = append(keys, key)
+		}
+	}
+
+	marks := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+	query := "select * from " + t.table + " where " + t.key + " in (" + marks + ")"
+
+	return p.auditRows(q, query, keys)
+}
+
+func findAuditKey(row map[string]interface{}, key string) (interface{}, bool) {
+	for col, v := range row {
+		if strings.EqualFold(col, key) {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+// auditRows selects the rows to the maps of the column names to the values,
+// the query is rewritten and converted for the driver like the exec.
+func (p *SQLParsed) auditRows(q Querier, query string, vars []interface{}) ([]map[string]interface{}, error) {
+	query, vars, err := p.replaceQuery(query, vars)
+	if err != nil {
+		return nil, fmt.Errorf("replaceQuery %s error %w", query, err)
+	}
+
+	rows, err := q.QueryContext(p.opt.Ctx, query, vars...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select audit rows by %s error %w", query, err)
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]map[string]interface{}, 0)
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		image := make(map[string]interface{}, len(columns))
+
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				image[col] = string(b)
+			} else {
+				image[col] = values[i]
+			}
+		}
+
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
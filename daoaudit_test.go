@@ -0,0 +1,203 @@
+package sqlx_test
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditDao struct {
+	CreateTable func()                                            `sql:"create table person(id varchar(100), age int)"`
+	CreateAudit func()                                            `sql:"create table audit_log(dao_id varchar(100), table_name varchar(100), action varchar(10), actor varchar(100), sql_text text, args text, before_image text, after_image text, created timestamp)"` // nolint:lll
+	Add         func(person)                                      `sql:"insert into person(id, age) values(:id, :age)"`
+	SetAge      func(ctx context.Context, age int, id string) int `sql:"update person set age = :1 where id = :2"`
+	Delete      func(person) int                                  `sql:"delete from person where age >= :age"`
+	SetID       func(id, old string) (int, error)                 `sql:"update person set id = :1 where id = :2"`
+	ResetID     func(old string) (int, error)                     `sql:"update person set id = '400' where id = :1"`
+	AppendID    func(id string) (int, error)                      `sql:"update person set id = id || 'x' where id = :1"`
+	Replace     func(person) error                                `sql:"insert or replace into person(id, age) values(:id, :age)"`
+	CountAudits func() int                                        `sql:"select count(*) from audit_log where action = 'update'"`
+}
+
+func TestAudit(t *testing.T) {
+	that := assert.New(t)
+
+	var records []sqlx.AuditRecord
+
+	sink := sqlx.AuditSinkFn(func(_ context.Context, _ sqlx.Querier, r sqlx.AuditRecord) error {
+		records = append(records, r)
+		return nil
+	})
+
+	dao := &auditDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithAudit(sink, "person")))
+
+	dao.CreateTable()
+	dao.Add(person{ID: "100", Age: 10})
+
+	// the statements which can't touch the auditable tables are not parsed for the audit.
+	that.Nil(dao.Replace(person{ID: "200", Age: 20}))
+	that.Len(records, 0)
+
+	that.Equal(1, dao.SetAge(sqlx.WithActor(context.Background(), "bingoo"), 11, "100"))
+	that.Len(records, 1)
+
+	r := records[0]
+	that.Equal("SetAge", r.DaoID)
+	that.Equal("person", r.Table)
+	that.Equal("update", r.Action)
+	that.Equal("bingoo", r.Actor)
+	that.Equal([]map[string]interface{}{{"id": "100", "age": int64(10)}}, r.Before)
+	that.Equal([]map[string]interface{}{{"id": "100", "age": int64(11)}}, r.After)
+
+	that.Equal(2, dao.Delete(person{Age: 5}))
+	that.Len(records, 2)
+
+	r = records[1]
+	that.Equal("delete", r.Action)
+	that.Equal([]map[string]interface{}{{"id": "100", "age": int64(11)}, {"id": "200", "age": int64(20)}}, r.Before)
+	that.Nil(r.After)
+
+	// the after images of the update of the key column are selected by the new key.
+	dao.Add(person{ID: "100", Age: 10})
+
+	n, err := dao.SetID("300", "100")
+	that.Nil(err)
+	that.Equal(1, n)
+	that.Len(records, 3)
+	that.Equal([]map[string]interface{}{{"id": "100", "age": int64(10)}}, records[2].Before)
+	that.Equal([]map[string]interface{}{{"id": "300", "age": int64(10)}}, records[2].After)
+
+	n, err = dao.ResetID("300")
+	that.Nil(err)
+	that.Equal(1, n)
+	that.Len(records, 4)
+	that.Equal([]map[string]interface{}{{"id": "400", "age": int64(10)}}, records[3].After)
+
+	// the new key of the expression is unknown before the exec.
+	_, err = dao.AppendID("400")
+	that.Error(err)
+	that.Len(records, 4)
+}
+
+func TestAuditNamedExec(t *testing.T) {
+	that := assert.New(t)
+
+	var records []sqlx.AuditRecord
+
+	sink := sqlx.AuditSinkFn(func(_ context.Context, q sqlx.Querier, r sqlx.AuditRecord) error {
+		_, inTx := q.(*sql.Tx)
+		that.True(inTx)
+
+		records = append(records, r)
+
+		return nil
+	})
+
+	db := openDB(t)
+	ctx := context.Background()
+	audit := sqlx.WithAudit(sink, "person")
+
+	_, err := sqlx.NamedExec(ctx, db, "create table person(id varchar(100), age int)", nil)
+	that.Nil(err)
+	_, err = sqlx.NamedExec(ctx, db, "insert into person(id, age) values(:id, :age)",
+		[]person{{ID: "100", Age: 10}, {ID: "200", Age: 20}}, audit)
+	that.Nil(err)
+
+	_, err = sqlx.NamedExec(ctx, db, "update person set age = :age where id = :id",
+		[]person{{ID: "100", Age: 11}, {ID: "200", Age: 21}}, audit)
+	that.Nil(err)
+	that.Len(records, 2)
+	that.Equal([]map[string]interface{}{{"id": "200", "age": int64(20)}}, records[1].Before)
+	that.Equal([]map[string]interface{}{{"id": "200", "age": int64(21)}}, records[1].After)
+}
+
+type auditTenantDao struct {
+	CreateTable func()                                          `sql:"create table person(id varchar(100), age int, tenant_id varchar(10))"`
+	Add         func(context.Context, person)                   `sql:"insert into person(id, age) values(:id, :age)"`
+	SetAge      func(context.Context, int, string) (int, error) `sql:"update person set age = :1 where id = :2"`
+}
+
+func TestAuditTenant(t *testing.T) {
+	that := assert.New(t)
+
+	var records []sqlx.AuditRecord
+
+	sink := sqlx.AuditSinkFn(func(_ context.Context, _ sqlx.Querier, r sqlx.AuditRecord) error {
+		records = append(records, r)
+		return nil
+	})
+
+	dao := &auditTenantDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithAudit(sink, "person"),
+		sqlx.WithTenant(sqlx.NewTenantRewriter("tenant_id", "person"))))
+
+	acme := sqlx.WithTenantID(context.Background(), "acme")
+	umbrella := sqlx.WithTenantID(context.Background(), "umbrella")
+
+	dao.CreateTable()
+	dao.Add(acme, person{ID: "100", Age: 10})
+	dao.Add(umbrella, person{ID: "100", Age: 20})
+
+	n, err := dao.SetAge(acme, 11, "100")
+	that.Nil(err)
+	that.Equal(1, n)
+	that.Len(records, 1)
+
+	// the audit selects are rewritten like the exec.
+	that.Equal([]map[string]interface{}{{"id": "100", "age": int64(10), "tenant_id": "acme"}}, records[0].Before)
+	that.Equal([]map[string]interface{}{{"id": "100", "age": int64(11), "tenant_id": "acme"}}, records[0].After)
+}
+
+func TestAuditTableAndFile(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &auditDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)),
+		sqlx.WithAudit(sqlx.AuditTableSink{Table: "audit_log"}, "person")))
+
+	dao.CreateTable()
+	dao.CreateAudit()
+	dao.Add(person{ID: "100", Age: 10})
+	that.Equal(1, dao.SetAge(context.Background(), 11, "100"))
+	that.Equal(0, dao.SetAge(context.Background(), 12, "200"))
+	that.Equal(2, dao.CountAudits())
+
+	dir, err := ioutil.TempDir("", "audit")
+	that.Nil(err)
+
+	defer os.RemoveAll(dir)
+
+	fileSink, err := sqlx.NewAuditFileSink(filepath.Join(dir, "audit.log"))
+	that.Nil(err)
+
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithAudit(fileSink, "person:id")))
+
+	dao.CreateTable()
+	dao.Add(person{ID: "100", Age: 10})
+	that.Equal(1, dao.SetAge(context.Background(), 11, "100"))
+	that.Nil(fileSink.Close())
+
+	f, err := os.Open(filepath.Join(dir, "audit.log"))
+	that.Nil(err)
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	that.True(scanner.Scan())
+
+	var r sqlx.AuditRecord
+	that.Nil(json.Unmarshal(scanner.Bytes(), &r))
+	that.Equal("update", r.Action)
+	that.Equal([]map[string]interface{}{{"id": "100", "age": float64(10)}}, r.Before)
+	that.Equal([]map[string]interface{}{{"id": "100", "age": float64(11)}}, r.After)
+	that.False(scanner.Scan())
+}
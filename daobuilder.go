@@ -0,0 +1,355 @@
+package sqlx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bingoohuang/sqlparser/sqlparser"
+)
+
+// Criteria defines the criteria of the where clause for the SelectBuilder.
+type Criteria interface {
+	toExpr(b *SelectBuilder) (sqlparser.Expr, error)
+}
+
+type (
+	// Eq is the equality criteria of the columns, like Eq{"status": 1},
+	// the nil value means IS NULL, and the slice value means IN.
+	Eq map[string]interface{}
+	// NotEq is the inequality criteria of the columns, the nil value means IS NOT NULL,
+	// and the slice value means NOT IN.
+	NotEq map[string]interface{}
+	// Gt is the greater than criteria of the columns.
+	Gt map[string]interface{}
+	// Gte is the greater than or equal criteria of the columns.
+	Gte map[string]interface{}
+	// Lt is the less than criteria of the columns.
+	Lt map[string]interface{}
+	// Lte is the less than or equal criteria of the columns.
+	Lte map[string]interface{}
+	// Like is the like criteria of the columns.
+	Like map[string]interface{}
+	// And is the conjunction of the criteria.
+	And []Criteria
+	// Or is the disjunction of the criteria.
+	Or []Criteria
+)
+
+// Expr is the raw criteria with ? placeholders, like Expr{SQL: "age > ? or age is null", Args: []interface{}{10}}.
+type Expr struct {
+	SQL  string
+	Args []interface{}
+}
+
+func (c Eq) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.compare(c, sqlparser.EqualStr)
+}
+
+func (c NotEq) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.compare(c, sqlparser.NotEqualStr)
+}
+
+func (c Gt) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.compare(c, sqlparser.GreaterThanStr)
+}
+
+func (c Gte) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.compare(c, sqlparser.GreaterEqualStr)
+}
+
+func (c Lt) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.compare(c, sqlparser.LessThanStr)
+}
+
+func (c Lte) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.compare(c, sqlparser.LessEqualStr)
+}
+
+func (c Like) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.compare(c, sqlparser.LikeStr)
+}
+
+func (c And) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.join(c, false)
+}
+
+func (c Or) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	return b.join(c, true)
+}
+
+func (c Expr) toExpr(b *SelectBuilder) (sqlparser.Expr, error) {
+	stmt, err := sqlparser.Parse("select 1 from dual where " + c.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse expr %s error %w", c.SQL, err)
+	}
+
+	expr := stmt.(*sqlparser.Select).Where.Expr
+
+	// the ? in the string literals are not placeholders.
+	if n := countValArgs(expr); n != len(c.Args) {
+		return nil, fmt.Errorf("expr %s requires %d args, but %d", c.SQL, n, len(c.Args)) // nolint:goerr113
+	}
+
+	// rewrite the ? placeholders to the seq bind marks in order.
+	i := 0
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if v, ok := node.(*sqlparser.SQLVal); ok && v.Type == sqlparser.ValArg {
+			v.Val = []byte(b.argMark(c.Args[i]))
+			i++
+		}
+
+		return true, nil
+	}, expr)
+
+	return &sqlparser.ParenExpr{Expr: expr}, nil
+}
+
+// SelectBuilder builds the select SQL on the sqlparser AST, with the seq bind marks like :1, :2
+// for the values in the criteria. The built SQL runs by its Select method like the ad-hoc Select,
+// which binds the values in the criteria, so it is not for the dao func SQL bound by the func arguments.
+type SelectBuilder struct {
+	sel  *sqlparser.Select
+	args []interface{}
+	err  error
+}
+
+// SelectFrom starts a SelectBuilder to select the columns (* when none) from the table.
+func SelectFrom(table string, columns ...string) *SelectBuilder {
+	cols := "*"
+	if len(columns) > 0 {
+		cols = strings.Join(columns, ", ")
+	}
+
+	b := &SelectBuilder{}
+
+	stmt, err := sqlparser.Parse("select " + cols + " from " + table)
+	if err != nil {
+		b.err = fmt.Errorf("failed to parse select from %s error %w", table, err)
+		return b
+	}
+
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		b.err = fmt.Errorf("bad select from %s", table) // nolint:goerr113
+		return b
+	}
+
+	b.sel = sel
+
+	return b
+}
+
+// Where adds the criteria to the where clause, multiple criteria are joined by and.
+func (b *SelectBuilder) Where(criteria ...Criteria) *SelectBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	for _, c := range criteria {
+		expr, err := c.toExpr(b)
+		if err != nil {
+			b.err = err
+			return b
+		}
+
+		if expr == nil {
+			continue
+		}
+
+		if b.sel.Where == nil {
+			b.sel.Where = sqlparser.NewWhere(sqlparser.WhereStr, expr)
+		} else {
+			b.sel.Where.Expr = &sqlparser.AndExpr{Left: b.sel.Where.Expr, Right: expr}
+		}
+	}
+
+	return b
+}
+
+// OrderBy adds the order by expressions, like OrderBy("age desc", "id").
+func (b *SelectBuilder) OrderBy(orders ...string) *SelectBuilder {
+	if b.err != nil || len(orders) == 0 {
+		return b
+	}
+
+	stmt, err := sqlparser.Parse("select 1 from dual order by " + strings.Join(orders, ", "))
+	if err != nil {
+		b.err = fmt.Errorf("failed to parse order by %v error %w", orders, err)
+		return b
+	}
+
+	b.sel.OrderBy = append(b.sel.OrderBy, stmt.(*sqlparser.Select).OrderBy...)
+
+	return b
+}
+
+// Limit sets the limit with the offset and the max rows.
+func (b *SelectBuilder) Limit(offset, length int64) *SelectBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	b.sel.Limit = &sqlparser.Limit{}
+
+	if offset > 0 {
+		b.sel.Limit.Offset = b.arg(offset)
+	}
+
+	b.sel.Limit.Rowcount = b.arg(length)
+
+	return b
+}
+
+// Args returns the bind variables for the seq bind marks in order.
+func (b *SelectBuilder) Args() []interface{} { return b.args }
+
+// Err returns the error occurred in building.
+func (b *SelectBuilder) Err() error { return b.err }
+
+// SQL returns the built SQL with the seq bind marks.
+func (b *SelectBuilder) SQL() string {
+	if b.err != nil {
+		return ""
+	}
+
+	return sqlparser.String(b.sel)
+}
+
+// Select executes the built SQL and scans the rows into dest like the package func Select,
+// and the args can be a *Count or the CreateDaoOpter.
+func (b *SelectBuilder) Select(ctx context.Context, q Querier, dest interface{}, args ...interface{}) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	part, err := adhocPart(b.SQL())
+	if err != nil {
+		return err
+	}
+
+	return adhocQuery(ctx, q, dest, part, append(append([]interface{}{}, b.args...), args...))
+}
+
+func (b *SelectBuilder) argMark(v interface{}) string {
+	b.args = append(b.args, v)
+	return ":" + strconv.Itoa(len(b.args))
+}
+
+func (b *SelectBuilder) arg(v interface{}) sqlparser.Expr {
+	return sqlparser.NewValArg([]byte(b.argMark(v)))
+}
+
+func (b *SelectBuilder) compare(m map[string]interface{}, op string) (sqlparser.Expr, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var exprs []sqlparser.Expr
+
+	for _, k := range keys {
+		col, err := parseColName(k)
+		if err != nil {
+			return nil, err
+		}
+
+		exprs = append(exprs, b.compareExpr(col, op, m[k]))
+	}
+
+	return joinExprs(exprs, false), nil
+}
+
+func (b *SelectBuilder) compareExpr(col sqlparser.Expr, op string, v interface{}) sqlparser.Expr {
+	if v == nil {
+		if op == sqlparser.NotEqualStr {
+			return &sqlparser.IsExpr{Operator: sqlparser.IsNotNullStr, Expr: col}
+		}
+
+		return &sqlparser.IsExpr{Operator: sqlparser.IsNullStr, Expr: col}
+	}
+
+	rv := reflect.ValueOf(v)
+	if (op == sqlparser.EqualStr || op == sqlparser.NotEqualStr) &&
+		rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		if rv.Len() == 0 { // in () is always false, and not in () is always true.
+			if op == sqlparser.EqualStr {
+				return &sqlparser.ComparisonExpr{Operator: sqlparser.EqualStr,
+					Left: sqlparser.NewIntVal([]byte("1")), Right: sqlparser.NewIntVal([]byte("0"))}
+			}
+
+			return nil
+		}
+
+		tuple := make(sqlparser.ValTuple, rv.Len())
+		for i := range tuple {
+			tuple[i] = b.arg(rv.Index(i).Interface())
+		}
+
+		inOp := sqlparser.InStr
+		if op == sqlparser.NotEqualStr {
+			inOp = sqlparser.NotInStr
+		}
+
+		return &sqlparser.ComparisonExpr{Operator: inOp, Left: col, Right: tuple}
+	}
+
+	return &sqlparser.ComparisonExpr{Operator: op, Left: col, Right: b.arg(v)}
+}
+
+func (b *SelectBuilder) join(criteria []Criteria, or bool) (sqlparser.Expr, error) {
+	exprs := make([]sqlparser.Expr, 0, len(criteria))
+
+	for _, c := range criteria {
+		expr, err := c.toExpr(b)
+		if err != nil {
+			return nil, err
+		}
+
+		exprs = append(exprs, expr)
+	}
+
+	return joinExprs(exprs, or), nil
+}
+
+func joinExprs(exprs []sqlparser.Expr, or bool) sqlparser.Expr {
+	var joined sqlparser.Expr
+
+	for _, expr := range exprs {
+		switch {
+		case expr == nil:
+			continue
+		case joined == nil:
+			joined = expr
+		case or:
+			joined = &sqlparser.OrExpr{Left: joined, Right: expr}
+		default:
+			joined = &sqlparser.AndExpr{Left: joined, Right: expr}
+		}
+	}
+
+	if joined == nil || len(exprs) == 1 {
+		return joined
+	}
+
+	return &sqlparser.ParenExpr{Expr: joined}
+}
+
+func parseColName(col string) (*sqlparser.ColName, error) {
+	parts := strings.Split(col, ".")
+
+	switch len(parts) {
+	case 1:
+		return &sqlparser.ColName{Name: sqlparser.NewColIdent(parts[0])}, nil
+	case 2:
+		return &sqlparser.ColName{Name: sqlparser.NewColIdent(parts[1]),
+			Qualifier: sqlparser.TableName{Name: sqlparser.NewTableIdent(parts[0])}}, nil
+	}
+
+	return nil, fmt.Errorf("bad column name %s", col) // nolint:goerr113
+}
@@ -0,0 +1,50 @@
+package sqlx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectBuilder(t *testing.T) {
+	that := assert.New(t)
+	ctx := context.Background()
+	db := openDB(t)
+
+	_, err := db.Exec("create table person(id varchar(100), age int, addr varchar(10))")
+	that.Nil(err)
+	_, err = db.Exec("insert into person(id, age, addr) values('10', 10, 'bj'), ('20', 20, null), ('30', 30, 'sh'), ('40', 40, 'gz')")
+	that.Nil(err)
+
+	b := sqlx.SelectFrom("person", "id", "age").
+		Where(sqlx.Gte{"age": 20}, sqlx.Or{sqlx.Eq{"addr": nil}, sqlx.Eq{"addr": []string{"sh", "gz"}}}).
+		OrderBy("age desc").
+		Limit(1, 2)
+
+	that.Equal("select id, age from person where age >= :1 and (addr is null or addr in (:2, :3)) "+
+		"order by age desc limit :4, :5", b.SQL())
+	that.Equal([]interface{}{20, "sh", "gz", int64(1), int64(2)}, b.Args())
+
+	var persons []person
+	var count sqlx.Count
+	that.Nil(b.Select(ctx, db, &persons, &count))
+	that.Equal([]person{{ID: "30", Age: 30}, {ID: "20", Age: 20}}, persons)
+	that.Equal(sqlx.Count(3), count)
+
+	var ids []string
+	that.Nil(sqlx.SelectFrom("person", "id").
+		Where(sqlx.Expr{SQL: "age < ? or age > ?", Args: []interface{}{15, 35}}, sqlx.Like{"addr": "%z"}).
+		Select(ctx, db, &ids))
+	that.Equal([]string{"40"}, ids)
+
+	// the ? in the string literal is not a placeholder.
+	ids = nil
+	that.Nil(sqlx.SelectFrom("person", "id").
+		Where(sqlx.Expr{SQL: "addr <> 'a?' and age = ?", Args: []interface{}{40}}).
+		Select(ctx, db, &ids))
+	that.Equal([]string{"40"}, ids)
+
+	that.Error(sqlx.SelectFrom("person").Where(sqlx.Expr{SQL: "age < ?"}).Err())
+}
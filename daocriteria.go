@@ -0,0 +1,103 @@
+package sqlx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// criteriaItem is a criteria part created from a field of the query-by-example struct.
+type criteriaItem struct {
+	part   string
+	vars   []interface{}
+	joined bool
+	group  string
+}
+
+// criteriaOps is the operators for the op tag, like `sql:"age" op:"gte"`.
+// nolint:gochecknoglobals
+var criteriaOps = map[string]string{
+	"eq": "=", "ne": "<>", "gt": ">", "gte": ">=", "lt": "<", "lte": "<=",
+}
+
+// makeCriteria creates the criteria part for the field by the op tag, the sql tag is the column name
+// when the op tag is specified, like `sql:"name" op:"like"`, or else the sql tag is the part itself.
+// The like wraps the value with % unless it contains % already, the in requires a slice value
+// which matches nothing when empty but not nil, and the between requires a slice or array value of two elements.
+func makeCriteria(sqlTag, op string, v reflect.Value) (string, []interface{}, error) {
+	switch op = strings.ToLower(op); op {
+	case "":
+		return sqlTag, []interface{}{v.Interface()}, nil
+	case "like":
+		s := fmt.Sprintf("%v", v.Interface())
+		if !strings.Contains(s, "%") {
+			s = "%" + s + "%"
+		}
+
+		return sqlTag + " like ?", []interface{}{s}, nil
+	case "in":
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return "", nil, fmt.Errorf("op in requires slice, but %v", v.Type()) // nolint:goerr113
+		}
+
+		if v.Len() == 0 { // in () matches nothing, the nil slice is skipped as the zero value
+			return "1 = 0", nil, nil
+		}
+
+		vars := make([]interface{}, v.Len())
+		for i := range vars {
+			vars[i] = v.Index(i).Interface()
+		}
+
+		return sqlTag + " in (" + strings.TrimSuffix(strings.Repeat("?,", len(vars)), ",") + ")", vars, nil
+	case "between":
+		if (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Len() != 2 {
+			return "", nil, fmt.Errorf("op between requires two elements, but %v", v.Interface()) // nolint:goerr113
+		}
+
+		return sqlTag + " between ? and ?", []interface{}{v.Index(0).Interface(), v.Index(1).Interface()}, nil
+	}
+
+	if sqlOp, ok := criteriaOps[op]; ok {
+		return sqlTag + " " + sqlOp + " ?", []interface{}{v.Interface()}, nil
+	}
+
+	return "", nil, fmt.Errorf("unknown op %s", op) // nolint:goerr113
+}
+
+// mergeOrGroups merges the items of the same or tag into one item like (a like ? or b like ?),
+// which is placed at the position of the first item in the group.
+func mergeOrGroups(items []criteriaItem) []criteriaItem {
+	merged := make([]criteriaItem, 0, len(items))
+	groups := make(map[string]int)
+	sizes := make(map[string]int)
+
+	for _, item := range items {
+		if item.group == "" {
+			merged = append(merged, item)
+			continue
+		}
+
+		sizes[item.group]++
+
+		pos, ok := groups[item.group]
+		if !ok {
+			groups[item.group] = len(merged)
+			merged = append(merged, criteriaItem{part: item.part, vars: item.vars, joined: true, group: item.group})
+
+			continue
+		}
+
+		g := &merged[pos]
+		g.part += " or " + item.part
+		g.vars = append(g.vars, item.vars...)
+	}
+
+	for i, item := range merged {
+		if sizes[item.group] > 1 {
+			merged[i].part = "(" + item.part + ")"
+		}
+	}
+
+	return merged
+}
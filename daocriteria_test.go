@@ -0,0 +1,57 @@
+package sqlx_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type criteriaFilter struct {
+	ID      string     `sql:"id" op:"like" or:"kw"`
+	Addr    string     `sql:"addr" op:"like" or:"kw"`
+	IDs     []string   `sql:"id" op:"in"`
+	Ages    [2]int     `sql:"age" op:"between"`
+	MinAge  int        `sql:"age" op:"gte"`
+	MaxAge  int        `sql:"age" op:"lte"`
+	NotAddr string     `sql:"addr" op:"ne"`
+	Limit   sqlx.Limit `sql:"limit ?,?"`
+}
+
+type criteriaDao struct {
+	CreateTable func()                                 `sql:"create table person(id varchar(100), age int, addr varchar(10))"`
+	Add         func(id string, age int, addr string)  `sql:"insert into person(id, age, addr) values(:1, :2, :3)"`
+	Query       func(criteriaFilter) ([]string, error) `sql:"select id from person"`
+}
+
+func TestCriteria(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &criteriaDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t))))
+
+	dao.CreateTable()
+	dao.Add("a10", 10, "bj")
+	dao.Add("b20", 20, "sh")
+	dao.Add("c30", 30, "ab")
+	dao.Add("d40", 40, "gz")
+
+	query := func(f criteriaFilter) []string {
+		ids, err := dao.Query(f)
+		that.Nil(err)
+		sort.Strings(ids)
+
+		return ids
+	}
+
+	that.Equal([]string{"a10", "b20", "c30", "d40"}, query(criteriaFilter{}))
+	that.Equal([]string{"a10", "c30"}, query(criteriaFilter{ID: "a", Addr: "a"}))
+	that.Equal([]string{"b20", "d40"}, query(criteriaFilter{IDs: []string{"b20", "d40", "x"}}))
+	that.Empty(query(criteriaFilter{IDs: []string{}}))
+	that.Empty(query(criteriaFilter{IDs: []string{}, ID: "a", Addr: "a"}))
+	that.Equal([]string{"b20", "c30"}, query(criteriaFilter{Ages: [2]int{15, 35}}))
+	that.Equal([]string{"c30"}, query(criteriaFilter{MinAge: 20, MaxAge: 30, NotAddr: "sh"}))
+	that.Equal([]string{"c30"}, query(criteriaFilter{ID: "a", Addr: "a", MinAge: 20}))
+	that.Equal([]string{"b20"}, query(criteriaFilter{MinAge: 20, Limit: sqlx.Limit{Length: 1}}))
+}
@@ -0,0 +1,238 @@
+package sqlx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyProvider provides the AES keys (16, 24 or 32 bytes) by name for the fields tagged with encrypt.
+type KeyProvider interface {
+	Key(name string) ([]byte, error)
+}
+
+// KeyProviderFn defines the func prototype of KeyProvider.
+type KeyProviderFn func(name string) ([]byte, error)
+
+// Key returns the key by name.
+func (f KeyProviderFn) Key(name string) ([]byte, error) { return f(name) }
+
+// nolint:gochecknoglobals
+var (
+	// ErrNoKeyProvider tells the field tagged with encrypt is used without KeyProvider.
+	ErrNoKeyProvider = errors.New("no KeyProvider specified for the encrypt field")
+)
+
+const gcmNonceSize = 12
+
+// encryptTag is the parsed tag like `encrypt:"phone,deterministic"`.
+// The deterministic encryption generates the same cipher text for the same plain text,
+// so that it can be used in equality lookups.
+type encryptTag struct {
+	keyName       string
+	deterministic bool
+}
+
+func parseEncryptTag(f reflect.StructField) *encryptTag {
+	tag := f.Tag.Get("encrypt")
+	if tag == "" {
+		return nil
+	}
+
+	parts := strings.Split(tag, ",")
+	t := &encryptTag{keyName: strings.TrimSpace(parts[0])}
+
+	for _, opt := range parts[1:] {
+		if strings.TrimSpace(opt) == "deterministic" {
+			t.deterministic = true
+		}
+	}
+
+	return t
+}
+
+// bindField converts the value of the field to the bind variable,
+// which is serialized to JSON or encrypted according to the field's tags.
+func (p *SQLParsed) bindField(v reflect.Value, field *reflect.StructField) (interface{}, error) {
+	if field == nil {
+		return bindValue(v, false)
+	}
+
+	val, err := bindValue(v, isJSONField(*field))
+	if err != nil {
+		return nil, err
+	}
+
+	tag := parseEncryptTag(*field)
+	if tag == nil || val == nil {
+		return val, nil
+	}
+
+	plain, err := plainBytes(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptBytes(p.opt.KeyProvider, tag, plain)
+}
+
+// encryptCriteria encrypts the vars of the criteria on the field tagged with encrypt in place,
+// which requires the deterministic encryption and the equality ops like eq, ne and in,
+// so that the cipher texts can match the column.
+// nolint:goerr113
+func (p *SQLParsed) encryptCriteria(f reflect.StructField, vars []interface{}) error {
+	tag := parseEncryptTag(f)
+	if tag == nil {
+		return nil
+	}
+
+	switch op := strings.ToLower(f.Tag.Get("op")); {
+	case !tag.deterministic:
+		return fmt.Errorf("criteria on the non-deterministic encrypt field is unsupported")
+	case op != "" && op != "eq" && op != "ne" && op != "in":
+		return fmt.Errorf("op %s on the encrypt field is unsupported", op)
+	}
+
+	for i, v := range vars {
+		ev, err := p.bindField(reflect.ValueOf(v), &f)
+		if err != nil {
+			return err
+		}
+
+		vars[i] = ev
+	}
+
+	return nil
+}
+
+// decryptField decrypts the cipher text from the column and sets the plain value to the field.
+func (s *structItem) decryptField(f reflect.Value, data []byte) error {
+	if len(data) == 0 {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+
+	plain, err := decryptBytes(s.keys, s.encrypt, string(data))
+	if err != nil {
+		return fmt.Errorf("decrypt field %s error %w", s.Name, err)
+	}
+
+	if s.json {
+		return unmarshalJSONField(f, plain)
+	}
+
+	n := &NullAny{Type: f.Type()}
+	if err := n.Scan(string(plain)); err != nil {
+		return fmt.Errorf("decrypt field %s error %w", s.Name, err)
+	}
+
+	f.Set(n.getVal())
+
+	return nil
+}
+
+// plainBytes formats the bind value to the plain text to encrypt.
+func plainBytes(val interface{}) ([]byte, error) {
+	dv, err := driver.DefaultParameterConverter.ConvertValue(val)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := dv.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case int64:
+		return []byte(strconv.FormatInt(v, 10)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case bool:
+		return []byte(strconv.FormatBool(v)), nil
+	case time.Time:
+		return []byte(v.Format(time.RFC3339Nano)), nil
+	}
+
+	return nil, fmt.Errorf("unsupported encrypt value type %T", dv) // nolint:goerr113
+}
+
+func createGCM(keys KeyProvider, keyName string) (cipher.AEAD, []byte, error) {
+	if keys == nil {
+		return nil, nil, ErrNoKeyProvider
+	}
+
+	key, err := keys.Key(keyName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get key %s error %w", keyName, err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("bad key %s error %w", keyName, err)
+	}
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, gcmNonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return gcm, key, nil
+}
+
+// encryptBytes encrypts the plain text by AES-GCM, and returns base64(nonce||cipher text).
+//
+// The deterministic encryption uses the synthetic nonce like AES-GCM-SIV, which is not in the standard library:
+// nonce = HMAC-SHA256(HMAC-SHA256(key, "sqlx.encrypt.nonce"), plain)[:12].
+// The nonce repeats only for the same plain text under the same key, which yields the same cipher text
+// as the lookups require and reveals nothing more than the equality. The distinct plain texts collide
+// on the 96-bit nonce with the birthday bound about 2^48 values per key, so rotate the key far before that.
+func encryptBytes(keys KeyProvider, tag *encryptTag, plain []byte) (string, error) {
+	gcm, key, err := createGCM(keys, tag.keyName)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+
+	if tag.deterministic {
+		sub := hmac.New(sha256.New, key)
+		_, _ = sub.Write([]byte("sqlx.encrypt.nonce"))
+
+		mac := hmac.New(sha256.New, sub.Sum(nil))
+		_, _ = mac.Write(plain)
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plain, nil)), nil
+}
+
+func decryptBytes(keys KeyProvider, tag *encryptTag, cipherText string) ([]byte, error) {
+	gcm, _, err := createGCM(keys, tag.keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcmNonceSize {
+		return nil, fmt.Errorf("too short cipher text %s", cipherText) // nolint:goerr113
+	}
+
+	return gcm.Open(nil, data[:gcmNonceSize], data[gcmNonceSize:], nil)
+}
@@ -0,0 +1,104 @@
+package sqlx_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type cryptPerson struct {
+	ID    string
+	Phone string `encrypt:"phone,deterministic"`
+	Card  string `encrypt:"card"`
+	Age   int    `encrypt:"card"`
+}
+
+type cryptFilter struct {
+	Phone string `encrypt:"phone,deterministic"`
+}
+
+type cryptCriteria struct {
+	Phone  string   `sql:"phone" op:"eq" encrypt:"phone,deterministic"`
+	Phones []string `sql:"phone" op:"in" encrypt:"phone,deterministic"`
+}
+
+type cryptBadCriteria struct {
+	Card string `sql:"card" encrypt:"card"`
+}
+
+type cryptDao struct {
+	CreateTable func()                                        `sql:"create table person(id varchar(100), phone varchar(100), card varchar(100), age varchar(100))"`
+	Add         func(cryptPerson)                             `sql:"insert into person(id, phone, card, age) values(:id, :phone, :card, :age)"`
+	FindByPhone func(cryptFilter) cryptPerson                 `sql:"select id, phone, card, age from person where phone=:phone"`
+	FindRaw     func(string) (string, string)                 `sql:"select phone, card from person where id=:1"`
+	FindBy      func(cryptCriteria) []cryptPerson             `sql:"select id, phone, card, age from person"`
+	FindByCard  func(cryptBadCriteria) ([]cryptPerson, error) `sql:"select id, phone, card, age from person"`
+}
+
+func TestEncrypt(t *testing.T) {
+	that := assert.New(t)
+
+	keys := sqlx.KeyProviderFn(func(name string) ([]byte, error) {
+		switch name {
+		case "phone":
+			return []byte("0123456789abcdef"), nil
+		case "card":
+			return []byte("0123456789abcdef0123456789abcdef"), nil
+		}
+
+		return nil, errors.New("unknown key " + name)
+	})
+
+	dao := &cryptDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithKeyProvider(keys)))
+
+	dao.CreateTable()
+
+	p := cryptPerson{ID: "10", Phone: "13800138000", Card: "110101199001011234", Age: 30}
+	dao.Add(p)
+
+	phone, card := dao.FindRaw("10")
+	that.NotContains(phone, "13800138000")
+	that.NotContains(card, "110101199001011234")
+
+	that.Equal(p, dao.FindByPhone(cryptFilter{Phone: "13800138000"}))
+	that.Equal(cryptPerson{}, dao.FindByPhone(cryptFilter{Phone: "13800138001"}))
+
+	// the deterministic cipher text is AES-GCM with the nonce synthesized from the plain text.
+	that.Equal(deterministicCipher([]byte("0123456789abcdef"), "13800138000"), phone)
+
+	// the criteria of the sql tags are encrypted like the named binding.
+	that.Equal([]cryptPerson{p}, dao.FindBy(cryptCriteria{Phone: "13800138000"}))
+	that.Equal([]cryptPerson{p}, dao.FindBy(cryptCriteria{Phones: []string{"13800138001", "13800138000"}}))
+	that.Empty(dao.FindBy(cryptCriteria{Phone: "13800138001"}))
+
+	_, err := dao.FindByCard(cryptBadCriteria{Card: "110101199001011234"})
+	that.Error(err, "the random nonce cipher text can not be looked up")
+
+	dao2 := &cryptDao{}
+	that.Nil(sqlx.CreateDao(dao2, sqlx.WithDB(openDB(t)), sqlx.WithError(&err)))
+	dao2.CreateTable()
+	dao2.Add(p)
+	that.True(errors.Is(err, sqlx.ErrNoKeyProvider))
+}
+
+func deterministicCipher(key []byte, plain string) string {
+	sub := hmac.New(sha256.New, key)
+	_, _ = sub.Write([]byte("sqlx.encrypt.nonce"))
+
+	mac := hmac.New(sha256.New, sub.Sum(nil))
+	_, _ = mac.Write([]byte(plain))
+	nonce := mac.Sum(nil)[:12]
+
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plain), nil))
+}
@@ -0,0 +1,102 @@
+package sqlx
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// nolint:gochecknoglobals
+var (
+	namedDBs     = make(map[string]*sql.DB)
+	namedDBsLock sync.RWMutex
+)
+
+// RegisterDB registers the db by name, which can be selected by the db tag like `db:"reporting"`
+// on the dao func field, or on the blank field _ of the dao struct for all its funcs.
+func RegisterDB(name string, db *sql.DB) {
+	namedDBsLock.Lock()
+	defer namedDBsLock.Unlock()
+
+	namedDBs[name] = db
+}
+
+// LookupDB looks up the registered db by name.
+func LookupDB(name string) (*sql.DB, bool) {
+	namedDBsLock.RLock()
+	defer namedDBsLock.RUnlock()
+
+	db, ok := namedDBs[name]
+
+	return db, ok
+}
+
+// NamedDB returns the DBGetter of the registered db by name, which is looked up at each call.
+func NamedDB(name string) DBGetter {
+	return GetDBFn(func() *sql.DB {
+		db, _ := LookupDB(name)
+		return db
+	})
+}
+
+// ViperRegisterDBs opens and registers the datasources configured by viper, like:
+//
+//	datasources:
+//	  reporting:
+//	    driver: mysql
+//	    dsn: user:pass@tcp(127.0.0.1:3306)/reporting
+func ViperRegisterDBs() error {
+	names := make([]string, 0)
+	for name := range viper.GetStringMap("datasources") {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		driver := viper.GetString("datasources." + name + ".driver")
+		dsn := viper.GetString("datasources." + name + ".dsn")
+
+		if driver == "" || dsn == "" {
+			return fmt.Errorf("datasource %s requires driver and dsn", name) // nolint:goerr113
+		}
+
+		db, err := NewSQLMore(driver, dsn).OpenE()
+		if err != nil {
+			return fmt.Errorf("failed to open datasource %s error %w", name, err)
+		}
+
+		RegisterDB(name, db)
+	}
+
+	return nil
+}
+
+// daoDBName returns the db tag on the blank field of the dao struct.
+func daoDBName(v reflect.Value) string {
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Type().Field(i); f.Name == "_" {
+			if name := f.Tag.Get("db"); name != "" {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// withNamedDB returns the option with the DBGetter of the registered db by name.
+func (option *CreateDaoOpt) withNamedDB(name string) (*CreateDaoOpt, error) {
+	if _, ok := LookupDB(name); !ok {
+		return nil, fmt.Errorf("db %s is not registered", name) // nolint:goerr113
+	}
+
+	opt := *option
+	opt.DBGetter = NamedDB(name)
+
+	return &opt, nil
+}
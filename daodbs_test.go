@@ -0,0 +1,72 @@
+package sqlx_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type dbsDao struct {
+	_ struct{} `db:"dbs_main"`
+
+	CreateTable       func()          `sql:"create table person(id varchar(100), age int)"`
+	CreateReportTable func()          `sql:"create table report(id varchar(100))" db:"dbs_reporting"`
+	Add               func(person)    `sql:"insert into person(id, age) values(:id, :age)"`
+	AddReport         func(string)    `sql:"insert into report(id) values(:1)" db:"dbs_reporting"`
+	Count             func() int      `sql:"select count(*) from person"`
+	Reports           func() []string `sql:"select id from report" db:"dbs_reporting"`
+}
+
+type badMainDbsDao struct {
+	_     struct{}   `db:"dbs_none"`
+	Count func() int `sql:"select count(*) from person"`
+}
+
+type badDbsDao struct {
+	Count func() int `sql:"select count(*) from person" db:"dbs_none"`
+}
+
+func TestNamedDBs(t *testing.T) {
+	that := assert.New(t)
+
+	sqlx.RegisterDB("dbs_main", openDB(t))
+
+	dir, err := ioutil.TempDir("", "dbs")
+	that.Nil(err)
+
+	defer os.RemoveAll(dir)
+
+	viper.Set("datasources", map[string]interface{}{
+		"dbs_reporting": map[string]interface{}{"driver": "sqlite3", "dsn": filepath.Join(dir, "reporting.db")},
+	})
+	defer viper.Set("datasources", nil)
+
+	that.Nil(sqlx.ViperRegisterDBs())
+
+	_, ok := sqlx.LookupDB("dbs_reporting")
+	that.True(ok)
+
+	dao := &dbsDao{}
+	that.Nil(sqlx.CreateDao(dao))
+
+	dao.CreateTable()
+	dao.CreateReportTable()
+	dao.Add(person{ID: "10", Age: 10})
+	dao.AddReport("r10")
+
+	that.Equal(1, dao.Count())
+	that.Equal([]string{"r10"}, dao.Reports())
+
+	err = sqlx.CreateDao(&badDbsDao{})
+	that.Error(err)
+	that.Contains(err.Error(), "db dbs_none is not registered")
+
+	err = sqlx.CreateDao(&badMainDbsDao{})
+	that.Error(err)
+	that.Contains(err.Error(), "db dbs_none is not registered")
+}
@@ -0,0 +1,115 @@
+package sqlx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// EnumValuer defines the enum type which converts itself to the stored database value.
+type EnumValuer interface {
+	DBValue() (interface{}, error)
+}
+
+// EnumScanner defines the enum type which converts the stored database value to itself.
+// It should be implemented by the pointer receiver.
+type EnumScanner interface {
+	FromDB(v interface{}) error
+}
+
+// enumMapping is the registered mapping between the enum constants and their database values.
+type enumMapping struct {
+	typ    reflect.Type
+	toDB   map[interface{}]interface{}
+	fromDB map[string]reflect.Value
+}
+
+// nolint:gochecknoglobals
+var (
+	enumMappings     = make(map[reflect.Type]*enumMapping)
+	enumMappingsLock sync.RWMutex
+)
+
+// RegisterEnum registers the enum type of the sample with the mapping from its constants to the database values,
+// like RegisterEnum(Red, map[Color]string{Red: "red", Green: "green"}).
+func RegisterEnum(sample interface{}, mapping interface{}) {
+	typ := reflect.TypeOf(sample)
+	mv := reflect.ValueOf(mapping)
+
+	if mv.Kind() != reflect.Map || mv.Type().Key() != typ {
+		panic(fmt.Errorf("enum mapping should be map[%v]xxx, but %v", typ, mv.Type())) // nolint:goerr113
+	}
+
+	m := &enumMapping{typ: typ, toDB: make(map[interface{}]interface{}), fromDB: make(map[string]reflect.Value)}
+
+	for _, k := range mv.MapKeys() {
+		dbValue := mv.MapIndex(k).Interface()
+		m.toDB[k.Interface()] = dbValue
+		m.fromDB[fmt.Sprint(dbValue)] = k
+	}
+
+	enumMappingsLock.Lock()
+	defer enumMappingsLock.Unlock()
+
+	enumMappings[typ] = m
+}
+
+func lookupEnum(typ reflect.Type) *enumMapping {
+	enumMappingsLock.RLock()
+	defer enumMappingsLock.RUnlock()
+
+	return enumMappings[typ]
+}
+
+// enumDBValue converts the enum value to its database value, ok is false when it is not an enum.
+func enumDBValue(v reflect.Value) (dbValue interface{}, ok bool, err error) {
+	if ev, yes := v.Interface().(EnumValuer); yes {
+		dbValue, err = ev.DBValue()
+		return dbValue, true, err
+	}
+
+	if v.CanAddr() {
+		if ev, yes := v.Addr().Interface().(EnumValuer); yes {
+			dbValue, err = ev.DBValue()
+			return dbValue, true, err
+		}
+	}
+
+	m := lookupEnum(v.Type())
+	if m == nil {
+		return nil, false, nil
+	}
+
+	if dbValue, ok = m.toDB[v.Interface()]; !ok {
+		return nil, true, fmt.Errorf("unknown enum value %v of %v", v.Interface(), m.typ) // nolint:goerr113
+	}
+
+	return dbValue, true, nil
+}
+
+// scanEnum converts the database value to the enum of typ, ok is false when typ is not an enum.
+func scanEnum(typ reflect.Type, value interface{}) (v reflect.Value, ok bool, err error) {
+	if b, yes := value.([]byte); yes {
+		value = string(b)
+	}
+
+	if reflect.PtrTo(typ).Implements(reflect.TypeOf((*EnumScanner)(nil)).Elem()) {
+		p := reflect.New(typ)
+		if err := p.Interface().(EnumScanner).FromDB(value); err != nil {
+			return v, true, fmt.Errorf("unknown enum value %v of %v error %w", value, typ, err)
+		}
+
+		return p.Elem(), true, nil
+	}
+
+	m := lookupEnum(typ)
+	if m == nil {
+		return v, false, nil
+	}
+
+	if v, ok = m.fromDB[fmt.Sprint(value)]; !ok {
+		return v, true, fmt.Errorf("unknown enum value %v of %v", value, typ) // nolint:goerr113
+	}
+
+	return v, true, nil
+}
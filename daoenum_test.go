@@ -0,0 +1,86 @@
+package sqlx_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type enumColor int
+
+const (
+	enumRed enumColor = iota + 1
+	enumGreen
+)
+
+type enumStatus string
+
+const (
+	enumActive   enumStatus = "active"
+	enumInactive enumStatus = "inactive"
+)
+
+func (s enumStatus) DBValue() (interface{}, error) {
+	switch s {
+	case enumActive:
+		return 1, nil
+	case enumInactive:
+		return 0, nil
+	}
+
+	return nil, fmt.Errorf("unknown status %s", string(s))
+}
+
+func (s *enumStatus) FromDB(v interface{}) error {
+	switch fmt.Sprint(v) {
+	case "1":
+		*s = enumActive
+	case "0":
+		*s = enumInactive
+	default:
+		return fmt.Errorf("unknown status %v", v)
+	}
+
+	return nil
+}
+
+type enumPerson struct {
+	ID     string
+	Color  enumColor
+	Status enumStatus
+}
+
+type enumDao struct {
+	CreateTable func()                           `sql:"create table person(id varchar(100), color varchar(10), status int)"`
+	Add         func(enumPerson)                 `sql:"insert into person(id, color, status) values(:id, :color, :status)"`
+	AddRaw      func(id, color string)           `sql:"insert into person(id, color, status) values(:1, :2, 1)"`
+	Find        func(string) enumPerson          `sql:"select id, color, status from person where id=:1"`
+	FindByColor func(enumColor) []string         `sql:"select id from person where color=:1"`
+	FindE       func(string) (enumPerson, error) `sql:"select id, color, status from person where id=:1"`
+}
+
+func TestEnum(t *testing.T) {
+	that := assert.New(t)
+
+	sqlx.RegisterEnum(enumRed, map[enumColor]string{enumRed: "red", enumGreen: "green"})
+
+	dao := &enumDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t))))
+
+	dao.CreateTable()
+	dao.Add(enumPerson{ID: "10", Color: enumRed, Status: enumActive})
+	dao.Add(enumPerson{ID: "20", Color: enumGreen, Status: enumInactive})
+
+	that.Equal(enumPerson{ID: "10", Color: enumRed, Status: enumActive}, dao.Find("10"))
+	that.Equal(enumPerson{ID: "20", Color: enumGreen, Status: enumInactive}, dao.Find("20"))
+	that.Equal([]string{"20"}, dao.FindByColor(enumGreen))
+
+	dao.AddRaw("30", "blue")
+
+	_, err := dao.FindE("30")
+	that.Error(err)
+	that.Contains(err.Error(), `"color"`)
+	that.Contains(err.Error(), "unknown enum value blue")
+}
@@ -0,0 +1,130 @@
+package sqlx
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// nolint:gochecknoglobals
+var (
+	// ErrDuplicateKey tells the unique or primary key is violated.
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrForeignKey tells the foreign key constraint is violated.
+	ErrForeignKey = errors.New("foreign key violation")
+	// ErrDeadlock tells the deadlock is detected.
+	ErrDeadlock = errors.New("deadlock")
+	// ErrLockTimeout tells the lock waiting is timeout.
+	ErrLockTimeout = errors.New("lock timeout")
+	// ErrNotNull tells the not null constraint is violated.
+	ErrNotNull = errors.New("not null violation")
+)
+
+// DBError is the database error occurred in the dao sql execution.
+// Use errors.Is(err, ErrDuplicateKey) and so on to test the classified kind.
+type DBError struct {
+	// Kind is the classified kind like ErrDuplicateKey, nil when unclassified.
+	Kind error
+	// ID is the dao sql ID.
+	ID string
+	// SQL is the executed sql.
+	SQL string
+	// Constraint is the involved constraint (key or column) name when available.
+	Constraint string
+	// Err is the raw driver error.
+	Err error
+}
+
+// Error returns the error message.
+func (e *DBError) Error() string { return fmt.Sprintf("execute %s error %v", e.SQL, e.Err) }
+
+// Unwrap returns the raw driver error.
+func (e *DBError) Unwrap() error { return e.Err }
+
+// Is tells whether the error is classified as the target kind.
+func (e *DBError) Is(target error) bool { return e.Kind != nil && e.Kind == target }
+
+// ErrorClassifier classifies the driver error to the kind like ErrDuplicateKey,
+// with the involved constraint name when available.
+type ErrorClassifier func(err error) (kind error, constraint string, ok bool)
+
+// nolint:gochecknoglobals
+var (
+	errorClassifiers     = []ErrorClassifier{ClassifyMySQLError}
+	errorClassifiersLock sync.RWMutex
+)
+
+// RegisterErrorClassifier registers a ErrorClassifier for a database driver.
+func RegisterErrorClassifier(classifier ErrorClassifier) {
+	errorClassifiersLock.Lock()
+	defer errorClassifiersLock.Unlock()
+
+	errorClassifiers = append(errorClassifiers, classifier)
+}
+
+// ClassifyError classifies the driver error by the registered classifiers.
+func ClassifyError(err error) (kind error, constraint string, ok bool) {
+	errorClassifiersLock.RLock()
+	defer errorClassifiersLock.RUnlock()
+
+	for _, classifier := range errorClassifiers {
+		if kind, constraint, ok := classifier(err); ok {
+			return kind, constraint, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// nolint:gochecknoglobals
+var (
+	mysqlKeyRe        = regexp.MustCompile(`for key '([^']+)'`)
+	mysqlConstraintRe = regexp.MustCompile("CONSTRAINT `([^`]+)`")
+	mysqlColumnRe     = regexp.MustCompile(`(?:Column|Field) '([^']+)'`)
+)
+
+// ClassifyMySQLError classifies the MySQL error by its error number.
+// refer https://dev.mysql.com/doc/refman/8.0/en/server-error-reference.html
+func ClassifyMySQLError(err error) (kind error, constraint string, ok bool) {
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return nil, "", false
+	}
+
+	switch me.Number {
+	case 1062, 1586: // ER_DUP_ENTRY, ER_DUP_ENTRY_WITH_KEY_NAME
+		return ErrDuplicateKey, submatch(mysqlKeyRe, me.Message), true
+	case 1022: // ER_DUP_KEY
+		return ErrDuplicateKey, "", true
+	case 1451, 1452: // ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+		return ErrForeignKey, submatch(mysqlConstraintRe, me.Message), true
+	case 1216, 1217: // ER_NO_REFERENCED_ROW, ER_ROW_IS_REFERENCED
+		return ErrForeignKey, "", true
+	case 1213: // ER_LOCK_DEADLOCK
+		return ErrDeadlock, "", true
+	case 1205: // ER_LOCK_WAIT_TIMEOUT
+		return ErrLockTimeout, "", true
+	case 1048, 1364: // ER_BAD_NULL_ERROR, ER_NO_DEFAULT_FOR_FIELD
+		return ErrNotNull, submatch(mysqlColumnRe, me.Message), true
+	}
+
+	return nil, "", false
+}
+
+func submatch(re *regexp.Regexp, s string) string {
+	if subs := re.FindStringSubmatch(s); len(subs) > 1 {
+		return subs[1]
+	}
+
+	return ""
+}
+
+func (p *SQLParsed) wrapDBError(query string, err error) error {
+	e := &DBError{ID: p.ID, SQL: query, Err: err}
+	e.Kind, e.Constraint, _ = ClassifyError(err)
+
+	return e
+}
@@ -0,0 +1,52 @@
+//go:build cgo
+// +build cgo
+
+package sqlx
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// nolint:gochecknoinits
+func init() { RegisterErrorClassifier(ClassifySQLiteError) }
+
+// ClassifySQLiteError classifies the SQLite error by its (extended) error code.
+// refer https://www.sqlite.org/rescode.html
+func ClassifySQLiteError(err error) (kind error, constraint string, ok bool) {
+	var se sqlite3.Error
+	if !errors.As(err, &se) {
+		return nil, "", false
+	}
+
+	switch se.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return ErrDuplicateKey, sqliteConstraint(se), true
+	case sqlite3.ErrConstraintForeignKey:
+		return ErrForeignKey, sqliteConstraint(se), true
+	case sqlite3.ErrConstraintNotNull:
+		return ErrNotNull, sqliteConstraint(se), true
+	}
+
+	switch se.Code {
+	case sqlite3.ErrBusy, sqlite3.ErrLocked:
+		return ErrLockTimeout, "", true
+	}
+
+	return nil, "", false
+}
+
+// sqliteConstraint parses the constraint like person.id from the message like
+// UNIQUE constraint failed: person.id.
+func sqliteConstraint(se sqlite3.Error) string {
+	const failed = "constraint failed:"
+
+	msg := se.Error()
+	if pos := strings.Index(msg, failed); pos >= 0 {
+		return strings.TrimSpace(msg[pos+len(failed):])
+	}
+
+	return ""
+}
@@ -0,0 +1,86 @@
+package sqlx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+type personErrDao struct {
+	CreateTable func() `sql:"create table person(id varchar(100) primary key, age int not null)"`
+	Add         func(person) error
+	AddAge      func(id string, age *int) error `sql:"insert into person(id, age) values(:1, :2)"`
+	Find        func(id string) (person, error) `sql:"select id, age from persons where id=:1"`
+}
+
+const dotSQLErr = `
+-- name: Add
+insert into person(id, age) values(:id, :age);
+`
+
+func TestDBError(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &personErrDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithSQLStr(dotSQLErr)))
+
+	dao.CreateTable()
+	that.Nil(dao.Add(person{"100", 100}))
+
+	err := dao.Add(person{"100", 100})
+	that.True(errors.Is(err, sqlx.ErrDuplicateKey))
+	that.False(errors.Is(err, sqlx.ErrNotNull))
+
+	var dbErr *sqlx.DBError
+
+	that.True(errors.As(err, &dbErr))
+	that.Equal("Add", dbErr.ID)
+	that.Equal("insert into person(id, age) values(?, ?)", dbErr.SQL)
+	that.Equal("person.id", dbErr.Constraint)
+
+	err = dao.AddAge("200", nil)
+	that.True(errors.Is(err, sqlx.ErrNotNull))
+	that.True(errors.As(err, &dbErr))
+	that.Equal("person.age", dbErr.Constraint)
+
+	_, err = dao.Find("100")
+	that.True(errors.As(err, &dbErr))
+	that.Nil(dbErr.Kind)
+	that.Equal("Find", dbErr.ID)
+}
+
+func TestClassifyMySQLError(t *testing.T) {
+	that := assert.New(t)
+
+	kind, constraint, ok := sqlx.ClassifyMySQLError(&mysql.MySQLError{
+		Number: 1062, Message: "Duplicate entry '100' for key 'uk_person_id'"})
+	that.True(ok)
+	that.Equal(sqlx.ErrDuplicateKey, kind)
+	that.Equal("uk_person_id", constraint)
+
+	kind, constraint, ok = sqlx.ClassifyMySQLError(&mysql.MySQLError{
+		Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+			"(`db`.`orders`, CONSTRAINT `fk_orders_person` FOREIGN KEY (`person_id`) REFERENCES `person` (`id`))"})
+	that.True(ok)
+	that.Equal(sqlx.ErrForeignKey, kind)
+	that.Equal("fk_orders_person", constraint)
+
+	kind, _, ok = sqlx.ClassifyMySQLError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"})
+	that.True(ok)
+	that.Equal(sqlx.ErrDeadlock, kind)
+
+	kind, _, ok = sqlx.ClassifyMySQLError(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"})
+	that.True(ok)
+	that.Equal(sqlx.ErrLockTimeout, kind)
+
+	kind, constraint, ok = sqlx.ClassifyMySQLError(&mysql.MySQLError{Number: 1048, Message: "Column 'age' cannot be null"})
+	that.True(ok)
+	that.Equal(sqlx.ErrNotNull, kind)
+	that.Equal("age", constraint)
+
+	_, _, ok = sqlx.ClassifyMySQLError(errors.New("other"))
+	that.False(ok)
+}
@@ -0,0 +1,95 @@
+package sqlx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BeforeInserter is the bean hook called before the named bind variables created for the INSERT SQL.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is the bean hook called before the named bind variables created for the UPDATE SQL.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterFinder is the bean hook called after a row is scanned into the bean.
+type AfterFinder interface {
+	AfterFind(ctx context.Context) error
+}
+
+// beforeExec calls the BeforeInsert or BeforeUpdate hook of the bean according to the first word of the SQL,
+// and returns the bean which may be modified by the hook. The struct value bean is copied to an addressable one
+// so that the hook can be implemented by the pointer receiver.
+func (p *SQLParsed) beforeExec(bean reflect.Value) (reflect.Value, error) {
+	if !bean.IsValid() {
+		return bean, nil
+	}
+
+	var call func(interface{}) (bool, error)
+
+	switch strings.ToUpper(FirstWord(p.RawStmt)) {
+	case "INSERT", "REPLACE":
+		call = func(v interface{}) (bool, error) {
+			h, ok := v.(BeforeInserter)
+			if !ok {
+				return false, nil
+			}
+
+			return true, h.BeforeInsert(p.opt.Ctx)
+		}
+	case "UPDATE":
+		call = func(v interface{}) (bool, error) {
+			h, ok := v.(BeforeUpdater)
+			if !ok {
+				return false, nil
+			}
+
+			return true, h.BeforeUpdate(p.opt.Ctx)
+		}
+	default:
+		return bean, nil
+	}
+
+	target := bean
+	if bean.Kind() == reflect.Struct {
+		target = reflect.New(bean.Type())
+		target.Elem().Set(bean)
+	} else if bean.Kind() != reflect.Ptr || bean.IsNil() {
+		return bean, nil
+	}
+
+	called, err := call(target.Interface())
+	if err != nil {
+		return bean, fmt.Errorf("[%s] hook error %w", p.ID, err)
+	}
+
+	if called && bean.Kind() == reflect.Struct {
+		return target.Elem(), nil
+	}
+
+	return bean, nil
+}
+
+// afterFind calls the AfterFind hook of the scanned struct value.
+func (p *SQLParsed) afterFind(out reflect.Value) error {
+	if out.Kind() == reflect.Struct && out.CanAddr() {
+		out = out.Addr()
+	}
+
+	if out.Kind() != reflect.Ptr || out.IsNil() {
+		return nil
+	}
+
+	if h, ok := out.Interface().(AfterFinder); ok {
+		if err := h.AfterFind(p.opt.Ctx); err != nil {
+			return fmt.Errorf("[%s] hook error %w", p.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package sqlx_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type hookPerson struct {
+	ID    string
+	Age   int
+	Title string
+}
+
+func (p *hookPerson) BeforeInsert(context.Context) error {
+	if p.Age < 0 {
+		return errors.New("negative age")
+	}
+
+	p.ID = strings.ToUpper(p.ID)
+
+	return nil
+}
+
+func (p *hookPerson) BeforeUpdate(context.Context) error {
+	p.Age++
+	return nil
+}
+
+func (p *hookPerson) AfterFind(context.Context) error {
+	p.Title = p.ID + "@" + strings.Repeat("*", p.Age/10)
+	return nil
+}
+
+type hookDao struct {
+	CreateTable func()                    `sql:"create table person(id varchar(100), age int)"`
+	Add         func(...hookPerson) error `sql:"insert into person(id, age) values(:id, :age)"`
+	Update      func(hookPerson)          `sql:"update person set age = :age where id = :id"`
+	Find        func(string) hookPerson   `sql:"select id, age from person where id=:1"`
+	Count       func() int                `sql:"select count(*) from person"`
+}
+
+func TestHooks(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &hookDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t))))
+
+	dao.CreateTable()
+
+	p := hookPerson{ID: "ab", Age: 10}
+	that.Nil(dao.Add(p))
+	that.Equal("ab", p.ID) // the caller's value is not modified.
+	that.Equal(hookPerson{ID: "AB", Age: 10, Title: "AB@*"}, dao.Find("AB"))
+
+	dao.Update(hookPerson{ID: "AB", Age: 20})
+	that.Equal(hookPerson{ID: "AB", Age: 21, Title: "AB@**"}, dao.Find("AB"))
+
+	err := dao.Add(hookPerson{ID: "cd", Age: 1}, hookPerson{ID: "ef", Age: -1})
+	that.Error(err)
+	that.Contains(err.Error(), "negative age")
+	that.Equal(1, dao.Count())
+}
@@ -0,0 +1,90 @@
+package sqlx
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// isJSONField tells whether the struct field is mapped to a JSON column,
+// by the tag `sqlx:"json"` or the option in the json tag like `json:"tags,sqljson"`.
+func isJSONField(f reflect.StructField) bool {
+	for _, opt := range strings.Split(f.Tag.Get("sqlx"), ",") {
+		if strings.TrimSpace(opt) == "json" {
+			return true
+		}
+	}
+
+	jsonTag := f.Tag.Get("json")
+	if pos := strings.Index(jsonTag, ","); pos >= 0 {
+		for _, opt := range strings.Split(jsonTag[pos+1:], ",") {
+			if opt == "sqljson" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// bindValue converts the value to the bind variable, the enum is converted to its database value,
+// the JSON field is serialized to the JSON text, and nil pointer, map or slice is bound as NULL.
+// The driver.Valuer, including the pointer with the pointer receiver Value, is passed to the driver as it is.
+func bindValue(v reflect.Value, jsonField bool) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	iv := indirectValue(v)
+	if !iv.IsValid() {
+		if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			return nil, nil
+		}
+
+		return v.Interface(), nil
+	}
+
+	if v.Type().Implements(_driverValuerType) {
+		return v.Interface(), nil
+	}
+
+	if dbValue, ok, err := enumDBValue(iv); ok {
+		return dbValue, err
+	}
+
+	if !jsonField {
+		return v.Interface(), nil
+	}
+
+	switch iv.Kind() {
+	case reflect.Map, reflect.Slice:
+		if iv.IsNil() {
+			return nil, nil
+		}
+	}
+
+	data, err := json.Marshal(iv.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("marshal json %v error %w", iv.Type(), err)
+	}
+
+	return string(data), nil
+}
+
+// unmarshalJSONField deserializes the JSON column value into the field, the NULL or empty value keeps the zero.
+func unmarshalJSONField(f reflect.Value, data []byte) error {
+	if len(data) == 0 {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+
+	p := reflect.New(f.Type())
+	if err := json.Unmarshal(data, p.Interface()); err != nil {
+		return fmt.Errorf("unmarshal json %s to %v error %w", data, f.Type(), err)
+	}
+
+	f.Set(p.Elem())
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package sqlx_test
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonProfile struct {
+	Nick  string `json:"nick"`
+	Level int    `json:"level"`
+}
+
+type jsonPerson struct {
+	ID      string
+	Profile *jsonProfile      `sqlx:"json"`
+	Tags    []string          `json:"tags,sqljson"`
+	Attrs   map[string]string `sqlx:"json"`
+}
+
+// csvTags is bound by its Value with the pointer receiver, other than JSON.
+type csvTags []string
+
+func (t *csvTags) Value() (driver.Value, error) { return strings.Join(*t, ","), nil }
+
+type jsonDao struct {
+	CreateTable func()                  `sql:"create table person(id varchar(100), profile text, tags text, attrs text)"`
+	Add         func(jsonPerson)        `sql:"insert into person(id, profile, tags, attrs) values(:id, :profile, :tags, :attrs)"`
+	AddTags     func(string, *csvTags)  `sql:"insert into person(id, tags) values(:1, :2)"`
+	Find        func(string) jsonPerson `sql:"select id, profile, tags, attrs from person where id=:1"`
+	FindTags    func(string) string     `sql:"select tags from person where id=:1"`
+}
+
+func TestJSONColumn(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &jsonDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t))))
+
+	dao.CreateTable()
+
+	p := jsonPerson{
+		ID:      "10",
+		Profile: &jsonProfile{Nick: "bingoo", Level: 3},
+		Tags:    []string{"a", "b"},
+		Attrs:   map[string]string{"k": "v"},
+	}
+	dao.Add(p)
+	that.Equal(p, dao.Find("10"))
+
+	dao.Add(jsonPerson{ID: "20"})
+	that.Equal(jsonPerson{ID: "20"}, dao.Find("20"))
+
+	// JSON is opt-in by the tags, the Valuer is used as it is.
+	dao.AddTags("30", &csvTags{"x", "y"})
+	that.Equal("x,y", dao.FindTags("30"))
+}
@@ -3,6 +3,7 @@ package sqlx
 import (
 	"database/sql"
 	"reflect"
+	"time"
 
 	"github.com/bingoohuang/gor"
 	"github.com/sirupsen/logrus"
@@ -16,6 +17,22 @@ type DaoLogger interface {
 	LogStart(id, sql string, vars interface{})
 }
 
+// SlowQuery tells the details of a slow dao sql execution.
+type SlowQuery struct {
+	ID   string
+	SQL  string
+	Vars interface{}
+	Cost time.Duration
+	// Plan is the EXPLAIN result of the slow SELECT, nil when not required.
+	Plan *ExecResult
+}
+
+// DaoSlowLogger is the optional interface for a DaoLogger to log the slow sql execution.
+type DaoSlowLogger interface {
+	// LogSlow logs the sql which cost exceeds the slow threshold
+	LogSlow(slow SlowQuery)
+}
+
 // nolint:gochecknoglobals
 var (
 	_daoLoggerType = reflect.TypeOf((*DaoLogger)(nil)).Elem()
@@ -31,6 +48,9 @@ func (d *DaoLoggerNoop) LogError(err error) { /*NOOP*/ }
 // LogStart logs the sql before the sql execution.
 func (d *DaoLoggerNoop) LogStart(id, sql string, vars interface{}) { /*NOOP*/ }
 
+// LogSlow logs the slow sql execution.
+func (d *DaoLoggerNoop) LogSlow(slow SlowQuery) { /*NOOP*/ }
+
 // DaoLogrus implements the interface for dao logging with logrus.
 type DaoLogrus struct{}
 
@@ -44,17 +64,41 @@ func (d *DaoLogrus) LogStart(id, sql string, vars interface{}) {
 	logrus.Debugf("start to exec %s [%s] with %v", id, sql, vars)
 }
 
-func createDBGetter(v reflect.Value, option *CreateDaoOpt) {
-	if option.DBGetter != nil {
+// LogSlow logs the slow sql execution.
+func (d *DaoLogrus) LogSlow(slow SlowQuery) {
+	if slow.Plan == nil {
+		logrus.Warnf("slow sql %s [%s] with %v cost %s", slow.ID, slow.SQL, slow.Vars, slow.Cost)
 		return
 	}
 
+	logrus.Warnf("slow sql %s [%s] with %v cost %s, plan %v %v",
+		slow.ID, slow.SQL, slow.Vars, slow.Cost, slow.Plan.Headers, slow.Plan.Rows)
+}
+
+func createDBGetter(v reflect.Value, option *CreateDaoOpt) error {
+	if option.DBGetter != nil {
+		return nil
+	}
+
+	if name := daoDBName(v); name != "" {
+		named, err := option.withNamedDB(name)
+		if err != nil {
+			return err
+		}
+
+		option.DBGetter = named.DBGetter
+
+		return nil
+	}
+
 	if fv := findTypedField(v, _dbGetterType); fv.IsValid() {
 		option.DBGetter = fv.Interface().(DBGetter)
-		return
+		return nil
 	}
 
 	option.DBGetter = GetDBFn(func() *sql.DB { return DB })
+
+	return nil
 }
 
 func createLogger(v reflect.Value, option *CreateDaoOpt) {
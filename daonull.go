@@ -36,6 +36,11 @@ func (n *NullAny) Scan(value interface{}) error {
 		return nil
 	}
 
+	if v, ok, err := scanEnum(n.Type, value); ok {
+		n.Val = v
+		return err
+	}
+
 	switch n.Type.Kind() {
 	case reflect.String:
 		sn := &sql.NullString{}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/bingoohuang/gor"
 	"github.com/bingoohuang/gor/defaults"
@@ -25,6 +26,20 @@ type CreateDaoOpt struct {
 	ErrSetter func(err error)
 
 	DBGetter DBGetter
+
+	SlowQueryThreshold time.Duration
+	SlowQueryExplain   bool
+
+	Strict bool
+
+	StmtCacheSize int
+
+	KeyProvider KeyProvider
+
+	Tenant    *TenantRewriter
+	Rewriters []QueryRewriter
+
+	audit *auditOpt
 }
 
 // CreateDaoOpter defines the option pattern interface for CreateDaoOpt.
@@ -58,6 +73,32 @@ func WithLogger(logger DaoLogger) CreateDaoOpter {
 	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.Logger = logger })
 }
 
+// WithSlowQueryThreshold specifies the threshold of the slow query logging.
+func WithSlowQueryThreshold(d time.Duration) CreateDaoOpter {
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.SlowQueryThreshold = d })
+}
+
+// WithSlowQueryExplain specifies to attach the EXPLAIN plan of the slow SELECT to the slow query log.
+func WithSlowQueryExplain() CreateDaoOpter {
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.SlowQueryExplain = true })
+}
+
+// WithStrict specifies to validate all the dao SQL eagerly when creating dao,
+// and all the problems are reported together in a MultiError.
+func WithStrict() CreateDaoOpter {
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.Strict = true })
+}
+
+// WithStmtCache specifies to cache at most size prepared statements for each dao function.
+func WithStmtCache(size int) CreateDaoOpter {
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.StmtCacheSize = size })
+}
+
+// WithKeyProvider specifies the KeyProvider for the fields tagged with encrypt.
+func WithKeyProvider(keys KeyProvider) CreateDaoOpter {
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.KeyProvider = keys })
+}
+
 // WithSQLFile imports SQL queries from the file.
 func WithSQLFile(sqlFile string) CreateDaoOpter {
 	return CreateDaoOptFn(func(opt *CreateDaoOpt) {
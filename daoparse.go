@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bingoohuang/sqlparser/sqlparser"
 )
@@ -15,9 +16,9 @@ func (p *SQLParsed) checkFuncInOut(numIn int, f StructField) error {
 		return fmt.Errorf("sql %s required bind varialbes, but the func %v has none", p.RawStmt, f.Type)
 	}
 
-	if numIn != 1 && p.isBindBy(ByName) {
-		return fmt.Errorf("sql %s required named varialbes, but the func %v has non-one arguments",
-			p.RawStmt, f.Type)
+	if names := argNames(f); len(names) > 0 && len(names) != numIn {
+		return fmt.Errorf("args tag %q has %d names, but the func %v has %d arguments",
+			f.Tag.Get("args"), len(names), f.Type, numIn)
 	}
 
 	if p.isBindBy(BySeq, ByAuto) {
@@ -73,14 +74,35 @@ type SQLParsed struct {
 
 	fp     FieldParts
 	runSQL string
+
+	runQuery string
+	runVars  []interface{}
+	runStart time.Time
+
+	stmts *stmtCache
+
+	script bool
 }
 
-func (p SQLParsed) replaceQuery(query string) (string, error) {
+// replaceQuery rewrites the query and its vars by the rewriters, converts the ? bind marks for the driver,
+// and replaces it by the global SQLReplacer, right before the execution.
+func (p SQLParsed) replaceQuery(query string, vars []interface{}) (string, []interface{}, error) {
+	query, vars, err := p.rewriteQuery(query, vars)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if p.opt != nil && p.opt.DBGetter != nil {
+		query = convertSQLBindMarks(p.opt.DBGetter.GetDB(), query)
+	}
+
 	if SQLReplacer == nil {
-		return query, nil
+		return query, vars, nil
 	}
 
-	return SQLReplacer.ReplacerQuery(query)
+	query, err = SQLReplacer.ReplacerQuery(query)
+
+	return query, vars, err
 }
 
 func (p SQLParsed) isBindBy(by ...bindBy) bool {
@@ -93,7 +115,8 @@ func (p SQLParsed) isBindBy(by ...bindBy) bool {
 	return false
 }
 
-var sqlre = regexp.MustCompile(`'?:\w*'?`)
+// sqlre matches the bind variables like :name, :1, :, ':name' and the dotted path like :user.address.city.
+var sqlre = regexp.MustCompile(`'?:\w*(?:\.\w+)*'?`)
 
 type FieldParts struct {
 	fieldParts []FieldPart
@@ -168,7 +191,7 @@ func (p *SQLParsed) parseSQL(runSQl string) error {
 	if len(p.fp.fieldParts) > 0 {
 		parsed, err := sqlparser.Parse(p.runSQL)
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("[%s] failed to parse sql %s error %w", p.ID, p.runSQL, err)
 		}
 
 		w, hasWhere := parsed.(sqlparser.IWhere)
@@ -192,10 +215,6 @@ func (p *SQLParsed) parseSQL(runSQl string) error {
 		}
 	}
 
-	if p.opt != nil && p.opt.DBGetter != nil {
-		p.runSQL = convertSQLBindMarks(p.opt.DBGetter.GetDB(), p.runSQL)
-	}
-
 	return nil
 }
 
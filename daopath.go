@@ -0,0 +1,313 @@
+package sqlx
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bingoohuang/strcase"
+)
+
+// maxEnvDepth limits the depth of the nested structs converted for the expr environment.
+const maxEnvDepth = 8
+
+// nolint:gochecknoglobals
+var _driverValuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// namedValue resolves the named bind variable on the bean, returns the value and
+// the struct field where the value comes from (nil when the value is from a map).
+// The name can be a dotted path like user.address.city or items.0.name, which walks through pointers,
+// embedded structs, nested maps and slice indexes. Any nil on the path resolves to an invalid value,
+// but the absent map key is an error like the absent struct field.
+func namedValue(bean reflect.Value, name string) (reflect.Value, *reflect.StructField, error) {
+	v := bean
+
+	var field *reflect.StructField
+
+	for _, seg := range strings.Split(name, ".") {
+		if v = indirectValue(v); !v.IsValid() {
+			return v, nil, nil
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			vt := v.Type()
+			sf, ok := vt.FieldByNameFunc(func(f string) bool { return matchesField2Col(vt, f, seg) })
+
+			if !ok {
+				return v, nil, fmt.Errorf("named var %s not found in %v", name, bean.Type()) // nolint:goerr113
+			}
+
+			if v = fieldByIndex(v, sf.Index); !v.IsValid() {
+				return v, nil, nil
+			}
+
+			field = &sf
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return v, nil, fmt.Errorf("named var %s requires string keyed map, but %v", name, v.Type()) // nolint:goerr113
+			}
+
+			mv := v.MapIndex(reflect.ValueOf(seg).Convert(v.Type().Key()))
+			if !mv.IsValid() {
+				return mv, nil, fmt.Errorf("named var %s not found in %v", name, v.Type()) // nolint:goerr113
+			}
+
+			v = mv
+
+			field = nil
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= v.Len() {
+				return v, nil, fmt.Errorf("named var %s has bad index %s for %v of length %d", // nolint:goerr113
+					name, seg, v.Type(), v.Len())
+			}
+
+			v, field = v.Index(i), nil
+		default:
+			return v, nil, fmt.Errorf("named var %s can not be resolved on %v", name, v.Type()) // nolint:goerr113
+		}
+	}
+
+	return v, field, nil
+}
+
+// indirectValue dereferences the pointers and interfaces, returns invalid value when nil met.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// fieldByIndex is the nil-safe version of reflect.Value.FieldByIndex,
+// it returns invalid value when a nil embedded pointer met.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			if v = indirectValue(v); !v.IsValid() {
+				return v
+			}
+		}
+
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// envValue converts the nested plain struct to map recursively for the expr environment,
+// so that the dotted path like user.address.city can be used in the dynamic SQL conditions.
+// The fields are keyed by both the name tag (or camel lower name) and the raw field name.
+func envValue(v reflect.Value, depth int) interface{} {
+	iv := indirectValue(v)
+	if depth >= maxEnvDepth || !iv.IsValid() {
+		return v.Interface()
+	}
+
+	switch iv.Kind() {
+	case reflect.Struct:
+		if !isPlainStruct(iv.Type()) {
+			return v.Interface()
+		}
+
+		m := make(map[string]interface{})
+		envFields(m, iv, depth+1)
+
+		return m
+	case reflect.Map:
+		if iv.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+
+		m := make(map[string]interface{})
+		for _, k := range iv.MapKeys() {
+			m[k.String()] = envValue(iv.MapIndex(k), depth+1)
+		}
+
+		return m
+	case reflect.Slice, reflect.Array:
+		if !isEnvElem(iv.Type().Elem()) {
+			return v.Interface()
+		}
+
+		s := make([]interface{}, iv.Len())
+		for i := range s {
+			s[i] = envValue(iv.Index(i), depth+1)
+		}
+
+		return s
+	}
+
+	return v.Interface()
+}
+
+// envFields puts the fields of the struct v into m, keyed by both the name tag (or camel lower name)
+// and the raw field name. The fields of the embedded structs are promoted like namedValue does,
+// and the shallower fields win, like the Go field selectors.
+func envFields(m map[string]interface{}, v reflect.Value, depth int) {
+	var embedded []int
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Anonymous && isPlainStruct(indirectType(f.Type)) {
+			embedded = append(embedded, i)
+			continue
+		}
+
+		if f.PkgPath != "" /* not exportable */ {
+			continue
+		}
+
+		fv := envValue(v.Field(i), depth)
+		m[f.Name] = fv
+
+		if tagName := f.Tag.Get("name"); tagName != "" {
+			m[tagName] = fv
+		} else {
+			m[strcase.ToCamelLower(f.Name)] = fv
+		}
+	}
+
+	for _, i := range embedded {
+		f := v.Type().Field(i)
+		if f.PkgPath == "" /* exportable */ {
+			putEnvAbsent(m, f.Name, envValue(v.Field(i), depth))
+		}
+
+		if depth >= maxEnvDepth {
+			continue
+		}
+
+		// the fields of the nil embedded pointer are promoted as zero values, so the conditions still work.
+		ev := indirectValue(v.Field(i))
+		if !ev.IsValid() {
+			ev = reflect.Zero(indirectType(f.Type))
+		}
+
+		promoted := make(map[string]interface{})
+		envFields(promoted, ev, depth+1)
+
+		for k, pv := range promoted {
+			putEnvAbsent(m, k, pv)
+		}
+	}
+}
+
+func putEnvAbsent(m map[string]interface{}, k string, v interface{}) {
+	if _, ok := m[k]; !ok {
+		m[k] = v
+	}
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+
+	return t
+}
+
+// isEnvElem tells whether the slice of the elements is converted for the expr environment,
+// only the slices of plain structs or string keyed maps are converted, like the items of the for loop.
+func isEnvElem(t reflect.Type) bool {
+	t = indirectType(t)
+
+	return isPlainStruct(t) || t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// isPlainStruct tells whether the t is a plain struct other than time, Valuer or Scanner.
+func isPlainStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !timeType.ConvertibleTo(t) &&
+		!t.Implements(_driverValuerType) && !ImplSQLScanner(reflect.PtrTo(t))
+}
+
+// findStructField finds the struct field for the column, returns the field and its index path.
+// Besides the direct and the promoted fields of the embedded structs, the nested struct fields
+// can be matched by the dotted column alias like address.city, or by the column prefix tag
+// like `prefix:"addr_"` on the nested struct field.
+func findStructField(t reflect.Type, col string, depth int) (reflect.StructField, []int, bool) {
+	if sf, ok := t.FieldByNameFunc(func(f string) bool { return matchesField2Col(t, f, col) }); ok {
+		return sf, sf.Index, true
+	}
+
+	if depth >= maxEnvDepth {
+		return reflect.StructField{}, nil, false
+	}
+
+	if pos := strings.Index(col, "."); pos > 0 {
+		head, rest := col[:pos], col[pos+1:]
+		if sf, ok := t.FieldByNameFunc(func(f string) bool { return matchesField2Col(t, f, head) }); ok {
+			if nested, index, ok := findNestedField(sf, rest, depth); ok {
+				return nested, index, true
+			}
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		prefix := sf.Tag.Get("prefix")
+
+		if prefix == "" || len(col) <= len(prefix) || !strings.EqualFold(col[:len(prefix)], prefix) {
+			continue
+		}
+
+		if nested, index, ok := findNestedField(sf, col[len(prefix):], depth); ok {
+			return nested, index, true
+		}
+	}
+
+	return reflect.StructField{}, nil, false
+}
+
+func findNestedField(sf reflect.StructField, col string, depth int) (reflect.StructField, []int, bool) {
+	ft := sf.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+
+	if !isPlainStruct(ft) {
+		return reflect.StructField{}, nil, false
+	}
+
+	nested, index, ok := findStructField(ft, col, depth+1)
+	if !ok {
+		return reflect.StructField{}, nil, false
+	}
+
+	return nested, append(append([]int{}, sf.Index...), index...), true
+}
+
+// fieldByIndexAlloc returns the field by the index path, allocating the nil pointers on the path.
+// When skipNil is true, the nil pointers are not allocated and an invalid value is returned,
+// so the nested pointer struct keeps nil when its columns are all null, like in a left join.
+func fieldByIndexAlloc(v reflect.Value, index []int, skipNil bool) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if skipNil {
+					return reflect.Value{}, nil
+				}
+
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("unable to set nil embedded pointer of unexported %v", v.Type()) // nolint:goerr113
+				}
+
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,64 @@
+package sqlx_test
+
+import (
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type pathAddress struct {
+	City string
+}
+
+type pathUser struct {
+	ID      string
+	Address *pathAddress
+}
+
+type pathBase struct {
+	Age int
+}
+
+type pathReq struct {
+	*pathBase
+	User pathUser
+}
+
+type pathDao struct {
+	CreateTable func()                             `sql:"create table person(id varchar(100), age int, city varchar(10))"`
+	Add         func(pathReq)                      `sql:"insert into person(id, age, city) values(:user.id, :age, :user.address.city)"`
+	AddMap      func(map[string]interface{})       `sql:"insert into person(id, age, city) values(:user.id, :age, :user.address.city)"`
+	TryAddMap   func(map[string]interface{}) error `sql:"insert into person(id, age) values(:user.id, :age)"`
+	Find        func(pathReq) []person             `sql:"select id, age from person where 1=1 /* if user.address.city != '' */ and city=:user.address.city /* end */ order by id"`
+	FindByAge   func(pathReq) []person             `sql:"select id, age from person where 1=1 /* if age > 0 */ and age>=:age /* end */ order by id"`
+}
+
+func TestNestedPath(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &pathDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t))))
+
+	dao.CreateTable()
+	dao.Add(pathReq{pathBase: &pathBase{Age: 10}, User: pathUser{ID: "10", Address: &pathAddress{City: "bj"}}})
+	dao.Add(pathReq{User: pathUser{ID: "20"}}) // nil pointers resolve to null
+	dao.AddMap(map[string]interface{}{
+		"age":  30,
+		"user": map[string]interface{}{"id": "30", "address": pathAddress{City: "sh"}},
+	})
+
+	that.Equal([]person{{ID: "10", Age: 10}},
+		dao.Find(pathReq{User: pathUser{Address: &pathAddress{City: "bj"}}}))
+	that.Equal([]person{{ID: "30", Age: 30}},
+		dao.Find(pathReq{User: pathUser{Address: &pathAddress{City: "sh"}}}))
+	that.Equal([]person{{ID: "10", Age: 10}, {ID: "20", Age: 0}, {ID: "30", Age: 30}},
+		dao.Find(pathReq{User: pathUser{Address: &pathAddress{}}}))
+
+	// the absent map key is an error, other than binding NULL silently.
+	that.Error(dao.TryAddMap(map[string]interface{}{"agee": 40, "user": map[string]interface{}{"id": "40"}}))
+	that.Error(dao.TryAddMap(map[string]interface{}{"age": 40, "user": map[string]interface{}{"iid": "40"}}))
+
+	that.Equal([]person{{ID: "30", Age: 30}}, dao.FindByAge(pathReq{pathBase: &pathBase{Age: 20}}))
+	that.Equal([]person{{ID: "10", Age: 10}, {ID: "20", Age: 0}, {ID: "30", Age: 30}}, dao.FindByAge(pathReq{}))
+}
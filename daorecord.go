@@ -0,0 +1,504 @@
+package sqlx
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrReplayMismatch tells the executed sql or args does not match the recorded one in replay mode.
+var ErrReplayMismatch = errors.New("replay mismatch")
+
+// Record is a single sql execution recorded in the golden file.
+type Record struct {
+	SQL          string          `json:"sql"`
+	Args         []RecordValue   `json:"args"`
+	Query        bool            `json:"query,omitempty"`
+	Columns      []string        `json:"columns,omitempty"`
+	Rows         [][]RecordValue `json:"rows,omitempty"`
+	RowsAffected int64           `json:"rowsAffected,omitempty"`
+	LastInsertID int64           `json:"lastInsertId,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// RecordValue is the typed driver.Value in the golden file.
+type RecordValue struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// MakeRecordValue makes a RecordValue from a driver.Value.
+func MakeRecordValue(v driver.Value) RecordValue {
+	switch vv := v.(type) {
+	case nil:
+		return RecordValue{Type: "nil"}
+	case int64:
+		return RecordValue{Type: "int64", Value: vv}
+	case float64:
+		return RecordValue{Type: "float64", Value: vv}
+	case bool:
+		return RecordValue{Type: "bool", Value: vv}
+	case []byte:
+		return RecordValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(vv)}
+	case string:
+		return RecordValue{Type: "string", Value: vv}
+	case time.Time:
+		return RecordValue{Type: "time", Value: vv.Format(time.RFC3339Nano)}
+	default:
+		return RecordValue{Type: "string", Value: fmt.Sprintf("%v", vv)}
+	}
+}
+
+// DriverValue converts the RecordValue back to the driver.Value.
+func (v RecordValue) DriverValue() (driver.Value, error) {
+	s := fmt.Sprintf("%v", v.Value)
+
+	switch v.Type {
+	case "nil":
+		return nil, nil
+	case "int64":
+		return strconv.ParseInt(s, 10, 64)
+	case "float64":
+		return strconv.ParseFloat(s, 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	case "bytes":
+		return base64.StdEncoding.DecodeString(s)
+	case "string":
+		return s, nil
+	case "time":
+		return time.Parse(time.RFC3339Nano, s)
+	default:
+		return nil, fmt.Errorf("unknown record value type %s", v.Type) // nolint:goerr113
+	}
+}
+
+func makeRecordArgs(args []driver.NamedValue) []RecordValue {
+	values := make([]RecordValue, len(args))
+	for i, arg := range args {
+		values[i] = MakeRecordValue(arg.Value)
+	}
+
+	return values
+}
+
+// Recorder records the executed sql, its args and its results to a golden file (JSON).
+// Recorder implements DBGetter.
+type Recorder struct {
+	file    string
+	db      *sql.DB
+	mu      sync.Mutex
+	records []*Record
+}
+
+// NewRecorder creates a Recorder which executes the sql on the real database
+// opened by driverName and dsn, and records them to the goldenFile.
+func NewRecorder(driverName, dsn, goldenFile string) (*Recorder, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	drv := db.Driver()
+	_ = db.Close()
+
+	r := &Recorder{file: goldenFile}
+	r.db = sql.OpenDB(&recordConnector{r: r, driver: drv, dsn: dsn})
+
+	return r, nil
+}
+
+// GetDB returns the recording sql.DB.
+func (r *Recorder) GetDB() *sql.DB { return r.db }
+
+// Records returns the records so far.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := make([]Record, len(r.records))
+	for i, rec := range r.records {
+		records[i] = *rec
+	}
+
+	return records
+}
+
+// Save saves the records to the golden file.
+func (r *Recorder) Save() error {
+	data, err := json.MarshalIndent(r.Records(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.file, data, 0644) // nolint:gosec,gomnd
+}
+
+// Close saves the records and closes the recording sql.DB.
+func (r *Recorder) Close() error {
+	if err := r.Save(); err != nil {
+		return err
+	}
+
+	return r.db.Close()
+}
+
+func (r *Recorder) add(rec *Record) *Record {
+	r.mu.Lock()
+	r.records = append(r.records, rec)
+	r.mu.Unlock()
+
+	return rec
+}
+
+type recordConnector struct {
+	r      *Recorder
+	driver driver.Driver
+	dsn    string
+}
+
+func (c *recordConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordConn{r: c.r, Conn: conn}, nil
+}
+
+func (c *recordConnector) Driver() driver.Driver { return c.driver }
+
+type recordConn struct {
+	driver.Conn
+	r *Recorder
+}
+
+func (c *recordConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *recordConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = pc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordStmt{r: c.r, query: query, Stmt: stmt}, nil
+}
+
+func (c *recordConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bc.BeginTx(ctx, opts)
+	}
+
+	return c.Conn.Begin() // nolint:staticcheck
+}
+
+func (c *recordConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+type recordStmt struct {
+	driver.Stmt
+	r     *Recorder
+	query string
+}
+
+func (s *recordStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *recordStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *recordStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var (
+		result driver.Result
+		err    error
+	)
+
+	if ec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = ec.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(plainValues(args)) // nolint:staticcheck
+	}
+
+	rec := &Record{SQL: s.query, Args: makeRecordArgs(args)}
+
+	if err != nil {
+		rec.Error = err.Error()
+	} else {
+		rec.RowsAffected, _ = result.RowsAffected()
+		rec.LastInsertID, _ = result.LastInsertId()
+	}
+
+	s.r.add(rec)
+
+	return result, err
+}
+
+func (s *recordStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var (
+		rows driver.Rows
+		err  error
+	)
+
+	if qc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(plainValues(args)) // nolint:staticcheck
+	}
+
+	rec := s.r.add(&Record{SQL: s.query, Args: makeRecordArgs(args), Query: true})
+
+	if err != nil {
+		s.r.mu.Lock()
+		rec.Error = err.Error()
+		s.r.mu.Unlock()
+
+		return nil, err
+	}
+
+	s.r.mu.Lock()
+	rec.Columns = rows.Columns()
+	rec.Rows = make([][]RecordValue, 0)
+	s.r.mu.Unlock()
+
+	return &recordRows{Rows: rows, r: s.r, rec: rec}, nil
+}
+
+type recordRows struct {
+	driver.Rows
+	r   *Recorder
+	rec *Record
+}
+
+func (r *recordRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		return err
+	}
+
+	row := make([]RecordValue, len(dest))
+	for i, v := range dest {
+		row[i] = MakeRecordValue(v)
+	}
+
+	r.r.mu.Lock()
+	r.rec.Rows = append(r.rec.Rows, row)
+	r.r.mu.Unlock()
+
+	return nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+
+	return named
+}
+
+func plainValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	return values
+}
+
+// Replayer serves the recorded results from the golden file back without a real database.
+// The executed sql and args should match the records strictly in order.
+// Replayer implements DBGetter.
+type Replayer struct {
+	db      *sql.DB
+	mu      sync.Mutex
+	records []Record
+	pos     int
+}
+
+// NewReplayer creates a Replayer from the golden file recorded by Recorder.
+func NewReplayer(goldenFile string) (*Replayer, error) {
+	data, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replayer{}
+
+	// UseNumber keeps the int64 values as they are, otherwise 1234567 decodes to float64 1.234567e+06.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&r.records); err != nil {
+		return nil, fmt.Errorf("failed to parse golden file %s error %w", goldenFile, err)
+	}
+
+	r.db = sql.OpenDB(&replayConnector{r: r})
+
+	return r, nil
+}
+
+// GetDB returns the replaying sql.DB.
+func (r *Replayer) GetDB() *sql.DB { return r.db }
+
+// Done tells whether all the records are replayed.
+func (r *Replayer) Done() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pos < len(r.records) {
+		// nolint:goerr113
+		return fmt.Errorf("%d records not replayed, next is #%d %s",
+			len(r.records)-r.pos, r.pos, r.records[r.pos].SQL)
+	}
+
+	return nil
+}
+
+func (r *Replayer) next(query string, args []driver.NamedValue, isQuery bool) (*Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	gotArgs := makeRecordArgs(args)
+
+	if r.pos >= len(r.records) {
+		return nil, fmt.Errorf("%w: no more records at #%d\n+sql:  %s\n+args: %s",
+			ErrReplayMismatch, r.pos, query, jsonString(gotArgs))
+	}
+
+	rec := &r.records[r.pos]
+	wantArgs, gotArgsJSON := jsonString(rec.Args), jsonString(gotArgs)
+
+	if rec.SQL != query || wantArgs != gotArgsJSON || rec.Query != isQuery {
+		return nil, fmt.Errorf("%w at #%d\n-sql:  %s\n+sql:  %s\n-args: %s\n+args: %s",
+			ErrReplayMismatch, r.pos, rec.SQL, query, wantArgs, gotArgsJSON)
+	}
+
+	r.pos++
+
+	if rec.Error != "" {
+		return nil, errors.New(rec.Error) // nolint:goerr113
+	}
+
+	return rec, nil
+}
+
+func jsonString(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+type replayConnector struct{ r *Replayer }
+
+func (c *replayConnector) Connect(context.Context) (driver.Conn, error) {
+	return &replayConn{r: c.r}, nil
+}
+func (c *replayConnector) Driver() driver.Driver            { return c }
+func (c *replayConnector) Open(string) (driver.Conn, error) { return &replayConn{r: c.r}, nil }
+
+type replayConn struct{ r *Replayer }
+
+func (c *replayConn) Prepare(query string) (driver.Stmt, error) {
+	return &replayStmt{r: c.r, query: query}, nil
+}
+
+func (c *replayConn) Close() error              { return nil }
+func (c *replayConn) Begin() (driver.Tx, error) { return replayTx{}, nil }
+
+type replayTx struct{}
+
+func (replayTx) Commit() error   { return nil }
+func (replayTx) Rollback() error { return nil }
+
+type replayStmt struct {
+	r     *Replayer
+	query string
+}
+
+func (s *replayStmt) Close() error  { return nil }
+func (s *replayStmt) NumInput() int { return -1 }
+
+func (s *replayStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *replayStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *replayStmt) ExecContext(_ context.Context, args []driver.NamedValue) (driver.Result, error) {
+	rec, err := s.r.next(s.query, args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return replayResult{rec: rec}, nil
+}
+
+func (s *replayStmt) QueryContext(_ context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rec, err := s.r.next(s.query, args, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &replayRows{rec: rec}, nil
+}
+
+type replayResult struct{ rec *Record }
+
+func (r replayResult) LastInsertId() (int64, error) { return r.rec.LastInsertID, nil }
+func (r replayResult) RowsAffected() (int64, error) { return r.rec.RowsAffected, nil }
+
+type replayRows struct {
+	rec *Record
+	pos int
+}
+
+func (r *replayRows) Columns() []string { return r.rec.Columns }
+func (r *replayRows) Close() error      { return nil }
+
+func (r *replayRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rec.Rows) {
+		return io.EOF
+	}
+
+	for i, v := range r.rec.Rows[r.pos] {
+		dv, err := v.DriverValue()
+		if err != nil {
+			return err
+		}
+
+		dest[i] = dv
+	}
+
+	r.pos++
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package sqlx_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+	that := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sqlx")
+	that.Nil(err)
+
+	defer os.RemoveAll(dir)
+
+	golden := filepath.Join(dir, "person.json")
+
+	recorder, err := sqlx.NewRecorder("sqlite3", ":memory:", golden)
+	that.Nil(err)
+	recorder.GetDB().SetMaxOpenConns(1)
+
+	dao := &personDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(recorder.GetDB())))
+
+	dao.CreateTable()
+	dao.AddAll(person{"100", 100}, person{"200", 200}, person{"300", 1234567})
+	that.Equal(person{"100", 100}, dao.Find("100"))
+	that.Equal([]person{{"100", 100}, {"200", 200}, {"300", 1234567}}, dao.ListAll())
+	that.Nil(recorder.Close())
+
+	replayer, err := sqlx.NewReplayer(golden)
+	that.Nil(err)
+
+	var daoErr error
+
+	replayDao := &personDao{}
+	that.Nil(sqlx.CreateDao(replayDao, sqlx.WithDB(replayer.GetDB()), sqlx.WithError(&daoErr)))
+
+	replayDao.CreateTable()
+	replayDao.AddAll(person{"100", 100}, person{"200", 200}, person{"300", 1234567})
+	that.Equal(person{"100", 100}, replayDao.Find("100"))
+	that.Equal([]person{{"100", 100}, {"200", 200}, {"300", 1234567}}, replayDao.ListAll())
+	that.Nil(daoErr)
+	that.Nil(replayer.Done())
+
+	replayer, err = sqlx.NewReplayer(golden)
+	that.Nil(err)
+	that.Nil(sqlx.CreateDao(replayDao, sqlx.WithDB(replayer.GetDB()), sqlx.WithError(&daoErr)))
+
+	replayDao.CreateTable()
+	replayDao.AddAll(person{"100", 101})
+	that.True(errors.Is(daoErr, sqlx.ErrReplayMismatch))
+	that.Contains(daoErr.Error(), `-args: [{"type":"string","value":"100"},{"type":"int64","value":100}]`)
+	that.Contains(daoErr.Error(), `+args: [{"type":"string","value":"100"},{"type":"int64","value":101}]`)
+	that.Error(replayer.Done())
+}
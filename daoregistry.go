@@ -0,0 +1,128 @@
+package sqlx
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// DaoFunc is the metadata of the dao func created by CreateDao.
+type DaoFunc struct {
+	// Dao is the type of the dao struct.
+	Dao string `json:"dao"`
+	// Name is the name of the func field.
+	Name string `json:"name"`
+	// SQLID is the sql name, which is the func name for the sql tag, or the dotsql name.
+	SQLID string `json:"sqlId"`
+	// RawSQL is the raw SQL with the bind variables replaced by ?.
+	RawSQL string `json:"rawSql"`
+	// BindBy is the bind mode, byNone, byAuto, bySeq or byName.
+	BindBy string `json:"bindBy"`
+	// Vars is the bind variables in the raw SQL.
+	Vars []string `json:"vars"`
+	// MaxSeq is the max seq of the bySeq bind variables.
+	MaxSeq int `json:"maxSeq"`
+	// IsQuery tells whether the SQL is a query.
+	IsQuery bool `json:"isQuery"`
+	// In is the input types of the func.
+	In []string `json:"in"`
+	// Out is the output types of the func.
+	Out []string `json:"out"`
+	// Source is where the SQL comes from, tag or dotsql.
+	Source string `json:"source"`
+}
+
+const (
+	sqlSourceTag    = "tag"
+	sqlSourceDotSQL = "dotsql"
+)
+
+type daoFuncKey struct {
+	dao  reflect.Type
+	name string
+}
+
+// nolint:gochecknoglobals
+var (
+	daoFuncs     = make(map[daoFuncKey]DaoFunc)
+	daoFuncsLock sync.RWMutex
+)
+
+// Registered returns the metadata of all the dao funcs created, sorted by the dao and the func name.
+func Registered() []DaoFunc {
+	daoFuncsLock.RLock()
+	funcs := make([]DaoFunc, 0, len(daoFuncs))
+
+	for _, f := range daoFuncs {
+		funcs = append(funcs, f)
+	}
+	daoFuncsLock.RUnlock()
+
+	sort.Slice(funcs, func(i, j int) bool {
+		if funcs[i].Dao != funcs[j].Dao {
+			return funcs[i].Dao < funcs[j].Dao
+		}
+
+		return funcs[i].Name < funcs[j].Name
+	})
+
+	return funcs
+}
+
+// RegistryHandler returns the http.Handler which renders the registered dao funcs as JSON,
+// the query parameter dao can be used to filter by the dao type.
+func RegistryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		funcs := Registered()
+
+		if dao := r.URL.Query().Get("dao"); dao != "" {
+			filtered := make([]DaoFunc, 0, len(funcs))
+
+			for _, f := range funcs {
+				if f.Dao == dao {
+					filtered = append(filtered, f)
+				}
+			}
+
+			funcs = filtered
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		if err := json.NewEncoder(w).Encode(funcs); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// register records the metadata of the dao func.
+func (p *SQLParsed) register(f StructField, source string) {
+	df := DaoFunc{
+		Dao:     f.Parent.StructSelf.Type().String(),
+		Name:    f.Name,
+		SQLID:   p.ID,
+		RawSQL:  p.RawStmt,
+		BindBy:  p.BindBy.String(),
+		Vars:    append([]string{}, p.Vars...),
+		MaxSeq:  p.MaxSeq,
+		IsQuery: p.IsQuery,
+		In:      make([]string, f.Type.NumIn()),
+		Out:     make([]string, f.Type.NumOut()),
+		Source:  source,
+	}
+
+	for i := range df.In {
+		df.In[i] = f.Type.In(i).String()
+	}
+
+	for i := range df.Out {
+		df.Out[i] = f.Type.Out(i).String()
+	}
+
+	daoFuncsLock.Lock()
+	defer daoFuncsLock.Unlock()
+
+	daoFuncs[daoFuncKey{dao: f.Parent.StructSelf.Type(), name: f.Name}] = df
+}
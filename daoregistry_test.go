@@ -0,0 +1,61 @@
+package sqlx_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type registryDao struct {
+	CreateTable func() `sql:"create table person(id varchar(100), age int)"`
+	Find        func(id string) (person, error)
+	Add         func(person) int `sql:"insert into person(id, age) values(:id, :age)"`
+}
+
+const registryDotSQL = `
+-- name: Find
+select id, age from person where id = :1;
+`
+
+func TestRegistry(t *testing.T) {
+	that := assert.New(t)
+
+	that.Nil(sqlx.CreateDao(&registryDao{}, sqlx.WithDB(openDB(t)), sqlx.WithSQLStr(registryDotSQL)))
+
+	var funcs []sqlx.DaoFunc
+
+	for _, f := range sqlx.Registered() {
+		if f.Dao == "sqlx_test.registryDao" {
+			funcs = append(funcs, f)
+		}
+	}
+
+	that.Equal([]sqlx.DaoFunc{
+		{
+			Dao: "sqlx_test.registryDao", Name: "Add", SQLID: "Add",
+			RawSQL: "insert into person(id, age) values(?, ?)", BindBy: "byName", Vars: []string{"id", "age"}, MaxSeq: 2,
+			In: []string{"sqlx_test.person"}, Out: []string{"int"}, Source: "tag",
+		},
+		{
+			Dao: "sqlx_test.registryDao", Name: "CreateTable", SQLID: "CreateTable",
+			RawSQL: "create table person(id varchar(100), age int)", BindBy: "byNone", Vars: []string{},
+			In: []string{}, Out: []string{}, Source: "tag",
+		},
+		{
+			Dao: "sqlx_test.registryDao", Name: "Find", SQLID: "Find",
+			RawSQL: "select id, age from person where id = ?", BindBy: "bySeq", Vars: []string{"1"}, MaxSeq: 1,
+			IsQuery: true, In: []string{"string"}, Out: []string{"sqlx_test.person", "error"}, Source: "dotsql",
+		},
+	}, funcs)
+
+	rec := httptest.NewRecorder()
+	sqlx.RegistryHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/?dao=sqlx_test.registryDao", nil))
+	that.Equal("application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	var rendered []sqlx.DaoFunc
+	that.Nil(json.Unmarshal(rec.Body.Bytes(), &rendered))
+	that.Equal(funcs, rendered)
+}
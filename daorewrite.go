@@ -0,0 +1,247 @@
+package sqlx
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bingoohuang/sqlparser/sqlparser"
+)
+
+// QueryRewriter rewrites the parsed SQL statement before executing.
+type QueryRewriter interface {
+	RewriteQuery(ctx context.Context, stmt sqlparser.Statement) (sqlparser.Statement, error)
+}
+
+// QueryRewriterFn is the func prototype of QueryRewriter.
+type QueryRewriterFn func(ctx context.Context, stmt sqlparser.Statement) (sqlparser.Statement, error)
+
+// RewriteQuery rewrites the parsed SQL statement.
+func (f QueryRewriterFn) RewriteQuery(ctx context.Context, stmt sqlparser.Statement) (sqlparser.Statement, error) {
+	return f(ctx, stmt)
+}
+
+// WithRewriters appends the QueryRewriters to the rewriting chain of the dao.
+// The rewriters are applied in order after the TenantRewriter, and before the global SQLReplacer.
+// Only the SELECT, INSERT, UPDATE and DELETE statements are rewritten.
+func WithRewriters(rewriters ...QueryRewriter) CreateDaoOpter {
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.Rewriters = append(opt.Rewriters, rewriters...) })
+}
+
+// TablePrefix creates a QueryRewriter which prefixes all the table names, like person to t_person.
+func TablePrefix(prefix string) QueryRewriter {
+	return QueryRewriterFn(func(_ context.Context, stmt sqlparser.Statement) (sqlparser.Statement, error) {
+		renameTables(stmt, func(t sqlparser.TableName) sqlparser.TableName {
+			t.Name = sqlparser.NewTableIdent(prefix + t.Name.String())
+			return t
+		})
+
+		return stmt, nil
+	})
+}
+
+// SchemaMap creates a QueryRewriter which maps the schemas of the tables for the environments,
+// like {"app": "app_test"} maps app.person to app_test.person,
+// and the empty key specifies the schema for the unqualified tables.
+func SchemaMap(schemas map[string]string) QueryRewriter {
+	return QueryRewriterFn(func(_ context.Context, stmt sqlparser.Statement) (sqlparser.Statement, error) {
+		renameTables(stmt, func(t sqlparser.TableName) sqlparser.TableName {
+			if schema, ok := schemas[t.Qualifier.String()]; ok {
+				t.Qualifier = sqlparser.NewTableIdent(schema)
+			}
+
+			return t
+		})
+
+		return stmt, nil
+	})
+}
+
+// UseIndex creates a QueryRewriter which adds the USE INDEX hint to the table.
+func UseIndex(table string, indexes ...string) QueryRewriter {
+	return indexHint(table, sqlparser.UseStr, indexes)
+}
+
+// ForceIndex creates a QueryRewriter which adds the FORCE INDEX hint to the table.
+func ForceIndex(table string, indexes ...string) QueryRewriter {
+	return indexHint(table, sqlparser.ForceStr, indexes)
+}
+
+// IgnoreIndex creates a QueryRewriter which adds the IGNORE INDEX hint to the table.
+func IgnoreIndex(table string, indexes ...string) QueryRewriter {
+	return indexHint(table, sqlparser.IgnoreStr, indexes)
+}
+
+func indexHint(table, hintType string, indexes []string) QueryRewriter {
+	hints := &sqlparser.IndexHints{Type: hintType, Indexes: make([]sqlparser.ColIdent, len(indexes))}
+	for i, index := range indexes {
+		hints.Indexes[i] = sqlparser.NewColIdent(index)
+	}
+
+	return QueryRewriterFn(func(_ context.Context, stmt sqlparser.Statement) (sqlparser.Statement, error) {
+		err := sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if e, ok := node.(*sqlparser.AliasedTableExpr); ok {
+				if name, ok := e.Expr.(sqlparser.TableName); ok && strings.EqualFold(name.Name.String(), table) {
+					e.Hints = hints
+				}
+			}
+
+			return true, nil
+		}, stmt)
+
+		return stmt, err
+	})
+}
+
+// ReplacerRewriter adapts the string level QueryReplacer to the QueryRewriter.
+func ReplacerRewriter(replacer QueryReplacer) QueryRewriter {
+	return QueryRewriterFn(func(_ context.Context, stmt sqlparser.Statement) (sqlparser.Statement, error) {
+		query, err := replacer.ReplacerQuery(sqlparser.String(stmt))
+		if err != nil {
+			return nil, err
+		}
+
+		return sqlparser.Parse(query)
+	})
+}
+
+// renameTables renames the tables, and the column qualifiers referring the tables, in the statement.
+func renameTables(stmt sqlparser.Statement, rename func(sqlparser.TableName) sqlparser.TableName) {
+	aliases := make(map[string]bool)
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if e, ok := node.(*sqlparser.AliasedTableExpr); ok && !e.As.IsEmpty() {
+			aliases[strings.ToLower(e.As.String())] = true
+		}
+
+		return true, nil
+	}, stmt)
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.AliasedTableExpr:
+			if name, ok := n.Expr.(sqlparser.TableName); ok {
+				n.Expr = rename(name)
+			}
+		case *sqlparser.Insert:
+			n.Table = rename(n.Table)
+		case *sqlparser.Delete:
+			for i, t := range n.Targets {
+				if !aliases[strings.ToLower(t.Name.String())] {
+					n.Targets[i] = rename(t)
+				}
+			}
+		case *sqlparser.ColName:
+			q := n.Qualifier
+			if !q.IsEmpty() && (!q.Qualifier.IsEmpty() || !aliases[strings.ToLower(q.Name.String())]) {
+				n.Qualifier = rename(q)
+			}
+		}
+
+		return true, nil
+	}, stmt)
+}
+
+// rewriteQuery rewrites the query by the TenantRewriter and the QueryRewriters of the option,
+// and arranges the vars with the tenant ID for the tenant bind marks.
+func (p SQLParsed) rewriteQuery(query string, vars []interface{}) (string, []interface{}, error) {
+	if p.opt == nil || p.opt.Tenant == nil && len(p.opt.Rewriters) == 0 {
+		return query, vars, nil
+	}
+
+	stmt, err := sqlparser.Parse(numberBindMarks(query))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse sql %s for rewriting error %w", query, err)
+	}
+
+	switch stmt.(type) {
+	case sqlparser.SelectStatement, *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete:
+	default:
+		return query, vars, nil
+	}
+
+	rewriters := p.opt.Rewriters
+	if p.opt.Tenant != nil {
+		rewriters = append([]QueryRewriter{p.opt.Tenant}, rewriters...)
+	}
+
+	for _, r := range rewriters {
+		if stmt, err = r.RewriteQuery(p.opt.Ctx, stmt); err != nil {
+			return "", nil, err
+		}
+	}
+
+	tenantID, _ := TenantID(p.opt.Ctx)
+	query, vars = bindStmt(stmt, vars, tenantID)
+
+	return query, vars, nil
+}
+
+// numberedArg is the prefix of the numbered bind marks, like :sqlx_v1 for the first ? of the query.
+const numberedArg = ":sqlx_v"
+
+// numberBindMarks renames the ? bind marks of the query to :sqlx_v1, :sqlx_v2... in order,
+// so that the vars are bound by the original positions of the marks after formatting,
+// which may reorder them, like limit ? offset ? formatted as limit ?, ? with the offset first.
+func numberBindMarks(query string) string {
+	var b strings.Builder
+
+	tkn := sqlparser.NewStringTokenizer(query)
+	last, n := 0, 0
+
+	for {
+		typ, val := tkn.Scan()
+		if typ == 0 || typ == sqlparser.LEX_ERROR {
+			break
+		}
+
+		// the lookahead char is read already, so the ? is the second last char read.
+		if pos := tkn.Position - 2; typ == sqlparser.VALUE_ARG && string(val) == "?" && query[pos] == '?' {
+			n++
+			b.WriteString(query[last:pos])
+			b.WriteString(numberedArg + strconv.Itoa(n))
+			last = pos + 1
+		}
+	}
+
+	b.WriteString(query[last:])
+
+	return b.String()
+}
+
+// bindStmt formats the statement with the ? bind marks, and arranges the vars in the order of the marks,
+// the numbered marks are bound to the vars at their numbers, and the tenant marks added
+// by the TenantRewriter are bound to the tenantID.
+func bindStmt(stmt sqlparser.SQLNode, vars []interface{}, tenantID interface{}) (string, []interface{}) {
+	bound := make([]interface{}, 0, len(vars)+1)
+	used := make([]bool, len(vars))
+
+	buf := sqlparser.NewTrackedBuffer(func(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+		v, ok := node.(*sqlparser.SQLVal)
+		if !ok || v.Type != sqlparser.ValArg {
+			node.Format(buf)
+			return
+		}
+
+		if name := string(v.Val); name == tenantArg {
+			bound = append(bound, tenantID)
+		} else if strings.HasPrefix(name, numberedArg) {
+			if i, err := strconv.Atoi(name[len(numberedArg):]); err == nil && i > 0 && i <= len(vars) {
+				bound = append(bound, vars[i-1])
+				used[i-1] = true
+			}
+		}
+
+		buf.WriteArg("?")
+	})
+	buf.Myprintf("%v", stmt)
+
+	for i, u := range used { // keeps the extra vars for the driver to report the mismatch.
+		if !u {
+			bound = append(bound, vars[i])
+		}
+	}
+
+	return buf.String(), bound
+}
@@ -0,0 +1,138 @@
+package sqlx_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type rewritePage struct {
+	Age   int        `sql:"age > ?"`
+	Limit sqlx.Limit `sql:"limit ?,?"`
+}
+
+type rewriteDao struct {
+	Find   func(id string) person                            `sql:"select p.id, p.age from app.person p where p.id = :1"`
+	Join   func(id string) []person                          `sql:"select person.id, dept.name from person join dept on person.dept = dept.id where person.id = :1"` // nolint:lll
+	Update func(ctx context.Context, age int, id string) int `sql:"update person set age = :1 where id = :2"`
+	Create func()                                            `sql:"create table person(id varchar(100))"`
+	Page   func(rewritePage) ([]person, sqlx.Count, error)   `sql:"select id, age from person"`
+
+	TenantPage func(context.Context, rewritePage) ([]person, sqlx.Count, error) `sql:"select id, age from person"`
+}
+
+func TestRewriters(t *testing.T) {
+	that := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	that.Nil(err)
+
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("select p.id, p.age from app_test.person as p force index (idx_id) where p.id = ?")).
+		WithArgs("100").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 10))
+	mock.ExpectQuery(regexp.QuoteMeta("select t_person.id, t_dept.name from t_person join t_dept on t_person.dept = t_dept.id " +
+		"where t_person.id = ?")).
+		WithArgs("100").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 10))
+	mock.ExpectQuery(regexp.QuoteMeta("select id, age from t_person where age > ? limit ?, ?")).
+		WithArgs(10, 0, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 20))
+	mock.ExpectQuery(regexp.QuoteMeta("select count(*) from t_person where age > ?")).
+		WithArgs(10).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta("update t_person_v2 set age = ? where id = ? and tenant_id = ?")).
+		WithArgs(20, "100", "acme").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("create table person(id varchar(100))")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	replacer := sqlx.QueryReplacerFn(func(query string) (string, error) {
+		return strings.Replace(query, "t_person", "t_person_v2", -1), nil
+	})
+
+	dao := &rewriteDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(db),
+		sqlx.WithRewriters(sqlx.SchemaMap(map[string]string{"app": "app_test"}), sqlx.ForceIndex("person", "idx_id"))))
+	that.Equal(person{ID: "100", Age: 10}, dao.Find("100"))
+
+	dao = &rewriteDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(db), sqlx.WithTenant(sqlx.NewTenantRewriter("tenant_id", "person")),
+		sqlx.WithRewriters(sqlx.TablePrefix("t_"))))
+	that.Len(dao.Join("100"), 0, "should fail closed without tenant")
+
+	dao = &rewriteDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(db), sqlx.WithRewriters(sqlx.TablePrefix("t_"))))
+	that.Equal([]person{{ID: "100", Age: 10}}, dao.Join("100"))
+
+	// the count query is rewritten once, not t_t_person.
+	persons, count, err := dao.Page(rewritePage{Age: 10, Limit: sqlx.Limit{Length: 2}})
+	that.Nil(err)
+	that.Equal([]person{{ID: "100", Age: 20}}, persons)
+	that.Equal(sqlx.Count(3), count)
+
+	dao = &rewriteDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(db), sqlx.WithTenant(sqlx.NewTenantRewriter("tenant_id", "person")),
+		sqlx.WithRewriters(sqlx.TablePrefix("t_"), sqlx.ReplacerRewriter(replacer))))
+	that.Equal(1, dao.Update(sqlx.WithTenantID(context.Background(), "acme"), 20, "100"))
+
+	dao.Create()
+
+	that.Nil(mock.ExpectationsWereMet())
+}
+
+func TestRewriteLimitOffset(t *testing.T) {
+	that := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	that.Nil(err)
+
+	defer db.Close()
+
+	// limit ? offset ? is formatted as limit ?, ? with the offset first, the vars follow the marks.
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(regexp.QuoteMeta("select id, age from t_person where age > ? limit ?, ?")).
+			WithArgs(10, 4, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 20))
+	}
+
+	ctx := context.Background()
+	opt := sqlx.WithRewriters(sqlx.TablePrefix("t_"))
+
+	var persons []person
+	that.Nil(sqlx.Select(ctx, db, &persons, "select id, age from person where age > ? limit ? offset ?", 10, 2, 4, opt))
+	that.Equal([]person{{ID: "100", Age: 20}}, persons)
+
+	persons = nil
+	that.Nil(sqlx.Select(ctx, db, &persons, "select id, age from person where age > ?", 10,
+		sqlx.Limit{Offset: 4, Length: 2}, opt))
+	that.Equal([]person{{ID: "100", Age: 20}}, persons)
+
+	that.Nil(mock.ExpectationsWereMet())
+}
+
+func TestRewriteTenantCount(t *testing.T) {
+	that := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	that.Nil(err)
+
+	defer db.Close()
+
+	mock.ExpectQuery("^"+regexp.QuoteMeta("select id, age from t_person where age > ? and tenant_id = ? limit ?, ?")+"$").
+		WithArgs(10, "acme", 0, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 20))
+	// the count query is filtered by the tenant exactly once.
+	mock.ExpectQuery("^"+regexp.QuoteMeta("select count(*) from t_person where age > ? and tenant_id = ?")+"$").
+		WithArgs(10, "acme").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	dao := &rewriteDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(db), sqlx.WithTenant(sqlx.NewTenantRewriter("tenant_id", "person")),
+		sqlx.WithRewriters(sqlx.TablePrefix("t_"))))
+
+	ctx := sqlx.WithTenantID(context.Background(), "acme")
+	persons, count, err := dao.TenantPage(ctx, rewritePage{Age: 10, Limit: sqlx.Limit{Length: 2}})
+	that.Nil(err)
+	that.Equal([]person{{ID: "100", Age: 20}}, persons)
+	that.Equal(sqlx.Count(3), count)
+
+	that.Nil(mock.ExpectationsWereMet())
+}
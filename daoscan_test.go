@@ -0,0 +1,65 @@
+package sqlx_test
+
+import (
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type scanAudit struct {
+	Creator string
+}
+
+type scanAddress struct {
+	City string
+	Zip  string
+}
+
+type scanPerson struct {
+	*scanAudit
+	ID      string
+	Home    scanAddress  `prefix:"home_"`
+	Address *scanAddress `name:"address"`
+}
+
+type ScanAudit struct {
+	Creator string
+}
+
+type scanPerson2 struct {
+	*ScanAudit
+	ID string
+}
+
+type scanDao struct {
+	CreateTable func()                                   `sql:"create table person(id varchar(100), creator varchar(10), home_city varchar(10), city varchar(10))"`
+	Add         func(id, creator, homeCity, city string) `sql:"insert into person(id, creator, home_city, city) values(:1, :2, :3, :4)"`
+	AddNoCity   func(id, homeCity string)                `sql:"insert into person(id, home_city) values(:1, :2)"`
+	Find        func(string) scanPerson                  `sql:"select id, home_city, city as \"address.city\" from person where id=:1"`
+	FindAudit   func(string) (scanPerson, error)         `sql:"select id, creator from person where id=:1"`
+	FindAudit2  func(string) scanPerson2                 `sql:"select id, creator from person where id=:1"`
+}
+
+func TestScanNested(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &scanDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t))))
+
+	dao.CreateTable()
+	dao.Add("10", "bingoo", "bj", "sh")
+	dao.Add("20", "bingoo", "gz", "")
+	dao.AddNoCity("30", "sz")
+
+	that.Equal(scanPerson{ID: "10", Home: scanAddress{City: "bj"}, Address: &scanAddress{City: "sh"}}, dao.Find("10"))
+	// the nested pointer struct is allocated for the non-null zero values.
+	that.Equal(scanPerson{ID: "20", Home: scanAddress{City: "gz"}, Address: &scanAddress{}}, dao.Find("20"))
+	// the nested pointer struct keeps nil when its columns are all null.
+	that.Equal(scanPerson{ID: "30", Home: scanAddress{City: "sz"}}, dao.Find("30"))
+
+	that.Equal(scanPerson2{ID: "10", ScanAudit: &ScanAudit{Creator: "bingoo"}}, dao.FindAudit2("10"))
+
+	_, err := dao.FindAudit("10")
+	that.Error(err)
+}
@@ -0,0 +1,300 @@
+package sqlx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scatter runs one query against multiple databases with identical schemas concurrently,
+// like the regional databases, and merges the rows.
+type Scatter struct {
+	// Targets are the databases to query.
+	Targets []DBGetter
+	// Parallelism limits the concurrent queries, 0 for all the targets at once.
+	Parallelism int
+	// Timeout limits the time of the query for each target, 0 for no limit.
+	Timeout time.Duration
+	// OrderBy re-sorts the merged rows by the columns, like "age desc", "id".
+	OrderBy []string
+	// Limit re-applies the limit to the merged rows, 0 for no limit.
+	Limit int
+}
+
+// ScatterFn queries the target, and returns the slice of the rows.
+type ScatterFn func(ctx context.Context, target DBGetter) (interface{}, error)
+
+// Select runs the ad-hoc query by Select against each target, and merges the rows into dest, like *[]Person.
+// It returns the per-target errors aligned with the Targets, nil for the succeeded targets,
+// so that the failures of some targets do not fail the whole call.
+func (s Scatter) Select(ctx context.Context, dest interface{}, query string,
+	args ...interface{}) ([]error, error) {
+	sliceType := reflect.TypeOf(dest)
+	if sliceType == nil || sliceType.Kind() != reflect.Ptr || sliceType.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("dest should be a pointer to slice, but %T", dest) // nolint:goerr113
+	}
+
+	return s.Run(ctx, dest, func(ctx context.Context, target DBGetter) (interface{}, error) {
+		rows := reflect.New(sliceType.Elem())
+		if err := Select(ctx, target.GetDB(), rows.Interface(), query, args...); err != nil {
+			return nil, err
+		}
+
+		return rows.Elem().Interface(), nil
+	})
+}
+
+// Run calls fn against each target concurrently, like calling the dao func created for the target,
+// and merges the returned slices into dest, like *[]Person.
+// It returns the per-target errors aligned with the Targets, nil for the succeeded targets.
+// nolint:goerr113
+func (s Scatter) Run(ctx context.Context, dest interface{}, fn ScatterFn) ([]error, error) {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || dv.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("dest should be a non-nil pointer to slice, but %T", dest)
+	}
+
+	less, err := scatterLess(dv.Elem().Type().Elem(), s.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, len(s.Targets))
+	errs := s.scatter(ctx, fn, results)
+	merged := reflect.MakeSlice(dv.Elem().Type(), 0, 0)
+
+	for i, result := range results {
+		if errs[i] != nil || result == nil {
+			continue
+		}
+
+		rv := reflect.ValueOf(result)
+		if rv.Type() != merged.Type() {
+			errs[i] = fmt.Errorf("scatter target %d returns %T, but %v required", i, result, merged.Type())
+			continue
+		}
+
+		merged = reflect.AppendSlice(merged, rv)
+	}
+
+	if less != nil {
+		sort.SliceStable(merged.Interface(), func(i, j int) bool { return less(merged.Index(i), merged.Index(j)) })
+	}
+
+	if s.Limit > 0 && merged.Len() > s.Limit {
+		merged = merged.Slice(0, s.Limit)
+	}
+
+	dv.Elem().Set(merged)
+
+	return errs, nil
+}
+
+func (s Scatter) scatter(ctx context.Context, fn ScatterFn, results []interface{}) []error {
+	errs := make([]error, len(s.Targets))
+	parallelism := s.Parallelism
+
+	if parallelism <= 0 || parallelism > len(s.Targets) {
+		parallelism = len(s.Targets)
+	}
+
+	sem := make(chan struct{}, parallelism)
+
+	var wg sync.WaitGroup
+
+	for i, target := range s.Targets {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, target DBGetter) {
+			defer wg.Done()
+
+			if results[i], errs[i] = s.call(ctx, fn, target, func() { <-sem }); errs[i] != nil {
+				errs[i] = fmt.Errorf("scatter target %d error %w", i, errs[i])
+			}
+		}(i, target)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+// call calls the fn against the target with the timeout,
+// it returns when the timeout reached, even if the fn does not respect the ctx.
+// The release is called after the fn returns, so the parallelism slot is held by the fn still running.
+func (s Scatter) call(ctx context.Context, fn ScatterFn, target DBGetter, release func()) (interface{}, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+
+		defer cancel()
+	}
+
+	type result struct {
+		v   interface{}
+		err error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		defer release()
+
+		v, err := fn(ctx, target)
+		done <- result{v: v, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// scatterLess creates the less func to sort the rows by the columns, nil for no sorting.
+// nolint:goerr113
+func scatterLess(elemType reflect.Type, orderBy []string) (func(a, b reflect.Value) bool, error) {
+	if len(orderBy) == 0 {
+		return nil, nil
+	}
+
+	t := elemType
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	type sortKey struct {
+		get  func(v reflect.Value) reflect.Value
+		desc bool
+	}
+
+	keys := make([]sortKey, len(orderBy))
+
+	for i, order := range orderBy {
+		fields := strings.Fields(order)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("bad order by %q", order)
+		}
+
+		col := fields[0]
+		keys[i].desc = len(fields) == 2 && strings.EqualFold(fields[1], "desc")
+
+		switch t.Kind() {
+		case reflect.Struct:
+			_, index, ok := findStructField(t, col, 0)
+			if !ok {
+				return nil, fmt.Errorf("order by column %s not found in %v", col, t)
+			}
+
+			keys[i].get = func(v reflect.Value) reflect.Value { return fieldByIndex(v, index) }
+		case reflect.Map:
+			if t.Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("unsupported order by for %v", elemType)
+			}
+
+			key := reflect.ValueOf(col).Convert(t.Key())
+			keys[i].get = func(v reflect.Value) reflect.Value { return mapIndexFold(v, key) }
+		default:
+			return nil, fmt.Errorf("unsupported order by for %v", elemType)
+		}
+	}
+
+	return func(a, b reflect.Value) bool {
+		a, b = indirectValue(a), indirectValue(b)
+
+		for _, k := range keys {
+			if c := compareValues(k.get(a), k.get(b)); c != 0 {
+				return k.desc != (c < 0)
+			}
+		}
+
+		return false
+	}, nil
+}
+
+// mapIndexFold returns the value of the key in the map, the key is matched case-insensitively
+// like the column names, the zero Value for the absent key.
+func mapIndexFold(m, key reflect.Value) reflect.Value {
+	if !m.IsValid() {
+		return reflect.Value{}
+	}
+
+	if v := m.MapIndex(key); v.IsValid() {
+		return v
+	}
+
+	for _, k := range m.MapKeys() {
+		if strings.EqualFold(k.String(), key.String()) {
+			return m.MapIndex(k)
+		}
+	}
+
+	return reflect.Value{}
+}
+
+// compareValues compares the values, the invalid or nil values are less than the others.
+func compareValues(a, b reflect.Value) int {
+	a, b = indirectValue(a), indirectValue(b)
+
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return 0
+	case !a.IsValid():
+		return -1
+	case !b.IsValid():
+		return 1
+	}
+
+	if a.Type() == timeType && b.Type() == timeType {
+		at, bt := a.Interface().(time.Time), b.Interface().(time.Time)
+		return compareOrdered(at.Before(bt), at.After(bt))
+	}
+
+	ak, bk := a.Kind(), b.Kind()
+
+	switch {
+	case isSignedKind(ak) && isSignedKind(bk):
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case isNumberKind(ak) && isNumberKind(bk):
+		af, bf := toFloat(a), toFloat(b)
+		return compareOrdered(af < bf, af > bf)
+	case ak == reflect.Bool && bk == reflect.Bool:
+		return compareOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	default:
+		return strings.Compare(fmt.Sprintf("%v", a.Interface()), fmt.Sprintf("%v", b.Interface()))
+	}
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isSignedKind(k reflect.Kind) bool { return k >= reflect.Int && k <= reflect.Int64 }
+
+func isFloatKind(k reflect.Kind) bool { return k == reflect.Float32 || k == reflect.Float64 }
+
+func isNumberKind(k reflect.Kind) bool { return isIntKind(k) || isFloatKind(k) }
+
+func toFloat(v reflect.Value) float64 {
+	switch k := v.Kind(); {
+	case isFloatKind(k):
+		return v.Float()
+	case isSignedKind(k):
+		return float64(v.Int())
+	default:
+		return float64(v.Uint())
+	}
+}
@@ -0,0 +1,116 @@
+package sqlx_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type scatterDao struct {
+	CreateTable func()                                               `sql:"create table person(id varchar(100), age int)"`
+	Add         func(person)                                         `sql:"insert into person(id, age) values(:id, :age)"`
+	Find        func(ctx context.Context, age int) ([]person, error) `sql:"select id, age from person where age >= :1"`
+}
+
+func TestScatter(t *testing.T) {
+	that := assert.New(t)
+
+	regions := [][]person{
+		{{ID: "east-1", Age: 10}, {ID: "east-2", Age: 40}},
+		{{ID: "west-1", Age: 30}, {ID: "west-2", Age: 20}, {ID: "west-3", Age: 50}},
+	}
+
+	daos := make(map[sqlx.DBGetter]*scatterDao)
+
+	var targets []sqlx.DBGetter
+
+	for _, rows := range regions {
+		db := sqlx.MakeDB(openDB(t))
+		dao := &scatterDao{}
+		that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(db.GetDB())))
+
+		dao.CreateTable()
+
+		for _, row := range rows {
+			dao.Add(row)
+		}
+
+		daos[db] = dao
+		targets = append(targets, db)
+	}
+
+	broken := sqlx.MakeDB(openDB(t))
+	scatter := sqlx.Scatter{
+		Targets:     append(targets, broken),
+		Parallelism: 2,
+		OrderBy:     []string{"age desc"},
+		Limit:       4,
+	}
+
+	var ps []person
+
+	errs, err := scatter.Select(context.Background(), &ps, "select id, age from person where age >= :1", 20)
+	that.Nil(err)
+	that.Equal([]person{{"west-3", 50}, {"east-2", 40}, {"west-1", 30}, {"west-2", 20}}, ps)
+	that.Nil(errs[0])
+	that.Nil(errs[1])
+	that.NotNil(errs[2], "the table is absent in the broken target")
+
+	scatter.Targets = targets
+	scatter.Timeout = 50 * time.Millisecond
+	scatter.OrderBy = []string{"ID desc"}
+	scatter.Limit = 0
+
+	var ms []map[string]string
+
+	// the map keys are matched case-insensitively, and the rows are re-sorted against the targets order.
+	errs, err = scatter.Select(context.Background(), &ms, "select id, age from person where age <= :1", 20)
+	that.Nil(err)
+	that.Equal([]error{nil, nil}, errs)
+	that.Equal([]map[string]string{{"id": "west-2", "age": "20"}, {"id": "east-1", "age": "10"}}, ms)
+
+	errs, err = scatter.Run(context.Background(), &ps, func(ctx context.Context, target sqlx.DBGetter) (interface{}, error) {
+		if target == targets[1] {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		return daos[target].Find(ctx, 0)
+	})
+
+	that.Nil(err)
+	that.Nil(errs[0])
+	that.True(errors.Is(errs[1], context.DeadlineExceeded))
+	that.Equal([]person{{"east-2", 40}, {"east-1", 10}}, ps)
+
+	_, err = scatter.Select(context.Background(), ps, "select id, age from person")
+	that.NotNil(err)
+
+	// the fn ignoring the ctx holds the parallelism slot after the timeout until it returns.
+	var running, maxRunning int32
+
+	scatter.Parallelism = 1
+	errs, err = scatter.Run(context.Background(), &ps, func(ctx context.Context, target sqlx.DBGetter) (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for m := atomic.LoadInt32(&maxRunning); n > m && !atomic.CompareAndSwapInt32(&maxRunning, m, n); {
+			m = atomic.LoadInt32(&maxRunning)
+		}
+
+		if target == targets[0] {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		return []person{}, nil
+	})
+
+	that.Nil(err)
+	that.True(errors.Is(errs[0], context.DeadlineExceeded))
+	that.Nil(errs[1])
+	that.Equal(int32(1), atomic.LoadInt32(&maxRunning))
+}
@@ -0,0 +1,179 @@
+package sqlx
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// nolint:gochecknoglobals
+var sqlResultType = reflect.TypeOf((*sql.Result)(nil)).Elem()
+
+// sqlDelimiter returns the delimiter attribute of the SQLPart, or ; by default.
+func sqlDelimiter(part SQLPart) string {
+	switch p := part.(type) {
+	case *PostProcessingSQLPart:
+		return MapValueOrDefault(p.Attrs, "delimiter", ";")
+	case *watchedPart:
+		if wp, err := p.w.part(p.name); err == nil {
+			return sqlDelimiter(wp)
+		}
+	}
+
+	return ";"
+}
+
+// splitScript splits the script into statements by the delimiter.
+func splitScript(script, delimiter string) []string {
+	if utf8.RuneCountInString(delimiter) == 1 {
+		r, _ := utf8.DecodeRuneInString(delimiter)
+		return SplitSqls(script, r)
+	}
+
+	var stmts []string
+	for _, s := range strings.Split(script, delimiter) {
+		stmts = tryAddSQL(stmts, s)
+	}
+
+	return stmts
+}
+
+// execScript executes the statements of the script sequentially in one transaction,
+// with the bindings shared among the statements.
+// It returns the last statement's result like the single statement func,
+// or the per-statement results when the func returns []sql.Result or integer slice of the rows affected.
+// nolint:funlen
+func (r *sqlRun) execScript(numIn int, f StructField, outTypes []reflect.Type,
+	args []reflect.Value) ([]reflect.Value, error) {
+	parsed := *r.SQLParsed
+
+	var (
+		bean reflect.Value
+		env  map[string]interface{}
+	)
+
+	if parsed.isBindBy(ByName) {
+		bean = namedBean(numIn, f, args)
+		env = parsed.createNamedMap(bean)
+	} else {
+		env = make(map[string]interface{})
+		for i, arg := range args {
+			env[fmt.Sprintf("_%d", i+1)] = arg.Interface()
+		}
+	}
+
+	script, err := parsed.SQL.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	db := r.opt.DBGetter.GetDB()
+	tx, err := db.BeginTx(parsed.opt.Ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx %w", err)
+	}
+
+	defer func() { _ = tx.Rollback() }() // no-op after committed
+
+	results := make([]sql.Result, 0)
+
+	for _, stmt := range splitScript(script, sqlDelimiter(parsed.SQL)) {
+		sp := parsed
+		if err := sp.parseSQL(stmt); err != nil {
+			return nil, err
+		}
+
+		if sp.BindBy, sp.MaxSeq, err = parseBindBy(sp.ID, sp.Vars); err != nil {
+			return nil, err
+		}
+
+		vars, err := sp.scriptVars(bean, args)
+		if err != nil {
+			return nil, err
+		}
+
+		query, runVars, err := r.replaceQuery(sp.runSQL, vars)
+		if err != nil {
+			return nil, fmt.Errorf("replaceQuery %s error %w", sp.runSQL, err)
+		}
+
+		sp.logPrepare(query, runVars)
+
+		start := time.Now()
+		result, err := sp.auditExec(tx, vars, func() (sql.Result, error) {
+			return tx.ExecContext(sp.opt.Ctx, query, runVars...)
+		})
+
+		if err != nil {
+			return nil, sp.wrapDBError(query, err)
+		}
+
+		sp.logSlow(tx, query, runVars, start)
+		results = append(results, result)
+		parsed.runSQL = query
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, parsed.wrapDBError(parsed.runSQL, err)
+	}
+
+	return convertScriptResults(results, parsed.runSQL, outTypes)
+}
+
+func (p *SQLParsed) scriptVars(bean reflect.Value, args []reflect.Value) ([]interface{}, error) {
+	switch {
+	case p.isBindBy(ByNone):
+		return nil, nil
+	case p.isBindBy(ByName):
+		if !bean.IsValid() {
+			return nil, fmt.Errorf("sql %s required named varialbes, but no arguments", p.runSQL) // nolint:goerr113
+		}
+
+		return p.createNamedVars(bean)
+	}
+
+	if n := len(p.Vars); p.BindBy == ByAuto && len(args) < n || p.BindBy == BySeq && len(args) < p.MaxSeq {
+		// nolint:goerr113
+		return nil, fmt.Errorf("sql %s required max %d vars, but only %d arguments", p.runSQL, max(n, p.MaxSeq), len(args))
+	}
+
+	return p.makeVars(args)
+}
+
+func convertScriptResults(results []sql.Result, lastSQL string, outTypes []reflect.Type) ([]reflect.Value, error) {
+	if len(outTypes) == 0 || outTypes[0].Kind() != reflect.Slice {
+		if len(results) == 0 {
+			return convertExecResult(driverResultNoRows{}, lastSQL, outTypes)
+		}
+
+		return convertExecResult(results[len(results)-1], lastSQL, outTypes)
+	}
+
+	out := reflect.MakeSlice(outTypes[0], len(results), len(results))
+
+	for i, result := range results {
+		if outTypes[0].Elem() == sqlResultType {
+			out.Index(i).Set(reflect.ValueOf(&result).Elem())
+			continue
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		out.Index(i).Set(reflect.ValueOf(rowsAffected).Convert(outTypes[0].Elem()))
+	}
+
+	values := []reflect.Value{out}
+	for i := 1; i < len(outTypes); i++ {
+		values = append(values, reflect.Zero(outTypes[i]))
+	}
+
+	return values, nil
+}
+
+// driverResultNoRows is the sql.Result when no statements executed.
+type driverResultNoRows struct{}
+
+func (driverResultNoRows) LastInsertId() (int64, error) { return 0, nil }
+func (driverResultNoRows) RowsAffected() (int64, error) { return 0, nil }
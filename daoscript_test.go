@@ -0,0 +1,63 @@
+package sqlx_test
+
+import (
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type scriptDao struct {
+	CreateTable func()                         `sql:"create table person(id varchar(100), age int); create table archive(id varchar(100), age int)"`
+	Add         func(person)                   `sql:"insert into person(id, age) values(:id, :age)"`
+	Archive     func(age int) ([]int64, error) `sql:"insert into archive select * from person where age < :1; delete from person where age < :1"`
+	Move        func(person) (int, error)      `sql:"delete from person where id = :id /* if age > 0 */ ; insert into archive(id, age) values(:id, :age) /* end */"`
+	Broken      func(person) error             `sql:"insert into archive(id, age) values(:id, :age); insert into nowhere(id) values(:id)"`
+	CountAll    func() (int, int)              `sql:"select (select count(*) from person), (select count(*) from archive)"`
+}
+
+const scriptDotSQL = `
+-- name: Swap delimiter: /
+update person set age = age + 1 where id = :1 /
+update archive set age = age - 1 where id = :1 /
+`
+
+type scriptDao2 struct {
+	Swap func(string) []int
+}
+
+func TestScript(t *testing.T) {
+	that := assert.New(t)
+	db := openDB(t)
+
+	dao := &scriptDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(db)))
+
+	dao.CreateTable()
+	dao.Add(person{ID: "10", Age: 10})
+	dao.Add(person{ID: "20", Age: 20})
+	dao.Add(person{ID: "30", Age: 30})
+
+	affected, err := dao.Archive(25)
+	that.Nil(err)
+	that.Equal([]int64{2, 2}, affected)
+
+	p, a := dao.CountAll()
+	that.Equal([]int{1, 2}, []int{p, a})
+
+	n, err := dao.Move(person{ID: "30", Age: 30})
+	that.Nil(err)
+	that.Equal(3, n) // the last insert id of archive.
+
+	p, a = dao.CountAll()
+	that.Equal([]int{0, 3}, []int{p, a})
+
+	that.Error(dao.Broken(person{ID: "40", Age: 40}))
+
+	p, a = dao.CountAll()
+	that.Equal([]int{0, 3}, []int{p, a}) // rolled back.
+
+	dao2 := &scriptDao2{}
+	that.Nil(sqlx.CreateDao(dao2, sqlx.WithDB(db), sqlx.WithSQLStr(scriptDotSQL)))
+	that.Equal([]int{0, 1}, dao2.Swap("10"))
+}
@@ -0,0 +1,34 @@
+package sqlx
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// logSlow logs the sql execution when it costs more than the slow query threshold.
+func (p *SQLParsed) logSlow(db SQLExec, query string, vars []interface{}, start time.Time) {
+	threshold := p.opt.SlowQueryThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	cost := time.Since(start)
+	if cost < threshold {
+		return
+	}
+
+	slow := SlowQuery{ID: p.ID, SQL: query, Vars: vars, Cost: cost}
+
+	if p.opt.SlowQueryExplain && db != nil && strings.EqualFold(FirstWord(query), "SELECT") {
+		plan := processQuery(db, "EXPLAIN "+query, "EXPLAIN", ExecOption{NullReplace: "NULL"}, vars...)
+		slow.Plan = &plan
+	}
+
+	if l, ok := p.opt.Logger.(DaoSlowLogger); ok {
+		l.LogSlow(slow)
+		return
+	}
+
+	log.Printf("W! slow sql %s [%s] with %v cost %s", slow.ID, slow.SQL, slow.Vars, slow.Cost)
+}
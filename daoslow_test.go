@@ -0,0 +1,43 @@
+package sqlx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type slowLogger struct {
+	sqlx.DaoLoggerNoop
+	slows []sqlx.SlowQuery
+}
+
+func (l *slowLogger) LogSlow(slow sqlx.SlowQuery) { l.slows = append(l.slows, slow) }
+
+func TestSlowQuery(t *testing.T) {
+	that := assert.New(t)
+
+	logger := &slowLogger{}
+	dao := &personDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithLogger(logger),
+		sqlx.WithSlowQueryThreshold(time.Nanosecond), sqlx.WithSlowQueryExplain()))
+
+	dao.CreateTable()
+	dao.Add(person{"100", 100})
+	that.Equal(person{"100", 100}, dao.Find("100"))
+
+	that.Len(logger.slows, 3)
+
+	find := logger.slows[2]
+	that.Equal("Find", find.ID)
+	that.Equal("select id, age from person where id=?", find.SQL)
+	that.Equal([]interface{}{"100"}, find.Vars)
+	that.True(find.Cost > 0)
+	that.NotNil(find.Plan)
+	that.Nil(find.Plan.Error)
+	that.NotEmpty(find.Plan.Rows)
+
+	that.Nil(logger.slows[0].Plan)
+	that.Nil(logger.slows[1].Plan)
+}
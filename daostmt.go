@@ -0,0 +1,137 @@
+package sqlx
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// stmtCache caches the prepared statements keyed by the final rendered SQL with LRU eviction.
+// The cache is invalidated when the DBGetter returns a different sql.DB.
+type stmtCache struct {
+	mu    sync.Mutex
+	size  int
+	db    *sql.DB
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// prepare returns the cached prepared statement of the query, or prepares a new one.
+// The returned release func should be called after the statement used.
+func (c *stmtCache) prepare(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db != db {
+		for c.ll.Len() > 0 {
+			c.evict(c.ll.Back())
+		}
+
+		c.db = db
+	}
+
+	if e, ok := c.items[query]; ok {
+		c.ll.MoveToFront(e)
+		return c.acquire(e.Value.(*stmtEntry))
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := &stmtEntry{query: query, stmt: stmt}
+	c.items[query] = c.ll.PushFront(entry)
+
+	for c.ll.Len() > c.size {
+		c.evict(c.ll.Back())
+	}
+
+	return c.acquire(entry)
+}
+
+func (c *stmtCache) acquire(entry *stmtEntry) (*sql.Stmt, func(), error) {
+	entry.refs++
+
+	return entry.stmt, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		entry.refs--
+		if entry.evicted && entry.refs == 0 {
+			_ = entry.stmt.Close()
+		}
+	}, nil
+}
+
+func (c *stmtCache) evict(e *list.Element) {
+	entry := c.ll.Remove(e).(*stmtEntry)
+	delete(c.items, entry.query)
+
+	entry.evicted = true
+	if entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+func (p *SQLParsed) queryContext(db *sql.DB, query string, vars []interface{}) (*sql.Rows, error) {
+	if p.stmts == nil {
+		return db.QueryContext(p.opt.Ctx, query, vars...)
+	}
+
+	stmt, release, err := p.stmts.prepare(p.opt.Ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return stmt.QueryContext(p.opt.Ctx, vars...)
+}
+
+// execContext executes the query with the runVars, the vars before the rewriting are for the audit.
+func (p *SQLParsed) execContext(db *sql.DB, query string, runVars, vars []interface{}) (sql.Result, error) {
+	if p.opt.audit != nil && p.opt.audit.mayAudit(p.runSQL) {
+		return p.execAudited(db, query, runVars, vars)
+	}
+
+	if p.stmts == nil {
+		return db.ExecContext(p.opt.Ctx, query, runVars...)
+	}
+
+	stmt, release, err := p.stmts.prepare(p.opt.Ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return stmt.ExecContext(p.opt.Ctx, runVars...)
+}
+
+func (p *SQLParsed) prepareTx(db *sql.DB, tx *sql.Tx, query string) (*sql.Stmt, error) {
+	if p.stmts == nil {
+		return tx.PrepareContext(p.opt.Ctx, query)
+	}
+
+	stmt, release, err := p.stmts.prepare(p.opt.Ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return tx.StmtContext(p.opt.Ctx, stmt), nil
+}
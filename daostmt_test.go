@@ -0,0 +1,84 @@
+package sqlx_test
+
+import (
+	"database/sql"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type stmtDao struct {
+	Find   func(id string) person          `sql:"select id, age from person where id=:1"`
+	FindBy func(id string, age int) person `sql:"select id, age from person where id=:1 /* if _2 > 0 */ and age=:2 /* end */"`
+	Delete func(id string) int             `sql:"delete from person where id=:1"`
+	DB     sqlx.DBGetter
+}
+
+func TestStmtCache(t *testing.T) {
+	that := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	that.Nil(err)
+
+	defer db.Close()
+
+	findSQL := `^select id, age from person where id=\?$`
+
+	prepare := mock.ExpectPrepare(findSQL).WillBeClosed()
+	prepare.ExpectQuery().WithArgs("100").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 100))
+	prepare.ExpectQuery().WithArgs("200").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("200", 200))
+
+	mock.ExpectPrepare(`^select id, age from person where id=\? and age=\?$`).
+		ExpectQuery().WithArgs("100", 100).WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 100))
+	mock.ExpectPrepare(`^delete from person where id=\?$`).
+		ExpectExec().WithArgs("100").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	dao := &stmtDao{DB: sqlx.MakeDB(db)}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithStmtCache(1)))
+
+	that.Equal(person{"100", 100}, dao.FindBy("100", 0))
+	that.Equal(person{"200", 200}, dao.FindBy("200", 0))
+
+	// the cache size is 1, so the stmt without the dynamic age part is evicted and closed.
+	that.Equal(person{"100", 100}, dao.FindBy("100", 100))
+	that.Equal(1, dao.Delete("100"))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestStmtCacheInvalidation(t *testing.T) {
+	that := assert.New(t)
+
+	db1, mock1, err := sqlmock.New()
+	that.Nil(err)
+
+	defer db1.Close()
+
+	db2, mock2, err := sqlmock.New()
+	that.Nil(err)
+
+	defer db2.Close()
+
+	findSQL := `^select id, age from person where id=\?$`
+
+	mock1.ExpectPrepare(findSQL).WillBeClosed().
+		ExpectQuery().WithArgs("100").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 100))
+	mock2.ExpectPrepare(findSQL).
+		ExpectQuery().WithArgs("100").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow("100", 101))
+
+	current := db1
+	dao := &stmtDao{DB: sqlx.GetDBFn(func() *sql.DB { return current })}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithStmtCache(10)))
+
+	that.Equal(person{"100", 100}, dao.Find("100"))
+
+	current = db2
+	that.Equal(person{"100", 101}, dao.Find("100"))
+
+	that.Nil(mock1.ExpectationsWereMet())
+	that.Nil(mock2.ExpectationsWereMet())
+}
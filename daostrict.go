@@ -0,0 +1,283 @@
+package sqlx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bingoohuang/gor"
+	"github.com/bingoohuang/sqlparser/sqlparser"
+)
+
+// MultiError collects multiple errors together.
+type MultiError []error
+
+// Error returns the messages of all the errors.
+func (m MultiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// ErrorOrNil returns nil when no errors collected, or the MultiError itself.
+func (m MultiError) ErrorOrNil() error {
+	if len(m) == 0 {
+		return nil
+	}
+
+	return m
+}
+
+// Unwrap returns the collected errors for errors.Is and errors.As.
+func (m MultiError) Unwrap() []error { return m }
+
+// Is tells whether any of the collected errors matches the target, like errors.Is.
+func (m MultiError) Is(target error) bool {
+	for _, err := range m {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first collected error that matches the target, like errors.As.
+func (m MultiError) As(target interface{}) bool {
+	for _, err := range m {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate validates the SQL and the func eagerly.
+// The dynamic SQL is evaluated with the zero values of the func's arguments.
+// The named vars resolved on the map argument are unknown in advance, which are reported
+// as unvalidated to the logger.
+// nolint:goerr113
+func (p *SQLParsed) validate(f StructField) MultiError {
+	var errs MultiError
+
+	numIn := f.Type.NumIn()
+	if hasCtxArg(f.Type) {
+		numIn--
+	}
+
+	numOut := f.Type.NumOut()
+
+	if numOut > 0 && gor.IsError(f.Type.Out(numOut-1)) {
+		numOut--
+	}
+
+	parsed := *p
+	env, bean, evaluable := parsed.sampleEnv(numIn, f)
+
+	if !evaluable {
+		p.opt.Logger.LogError(fmt.Errorf("[%s] sql %s is unvalidated for the map argument in strict mode",
+			p.ID, p.RawStmt))
+	}
+
+	runSQL, err := parsed.SQL.Eval(env)
+	if err != nil {
+		if evaluable {
+			errs = append(errs, fmt.Errorf("[%s] failed to eval sql %s error %w", p.ID, p.RawStmt, err))
+		}
+
+		return errs
+	}
+
+	if parsed.script {
+		return parsed.validateScript(runSQL, bean, evaluable)
+	}
+
+	if err := parsed.parseSQL(runSQL); err != nil {
+		return append(errs, err)
+	}
+
+	if err := parsed.checkFuncInOut(numIn, f); err != nil {
+		errs = append(errs, fmt.Errorf("[%s] %w", p.ID, err))
+	}
+
+	if parsed.isBindBy(ByName) && bean.IsValid() && evaluable {
+		if _, err := parsed.createNamedVars(bean); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] %w", p.ID, err))
+		}
+	}
+
+	stmt, err := sqlparser.Parse(sqlre.ReplaceAllString(runSQL, "?"))
+	if err != nil {
+		return append(errs, fmt.Errorf("[%s] failed to parse sql %s error %w", p.ID, runSQL, err))
+	}
+
+	outTypes := makeOutTypes(f.Type, numOut)
+	outTypes = remove(outTypes, indexOfTypes(outTypes, CountType))
+
+	if !p.IsQuery {
+		for _, t := range outTypes {
+			if !isIntKind(t.Kind()) {
+				errs = append(errs, fmt.Errorf("[%s] exec sql %s requires integer returns, but the func %v",
+					p.ID, p.RawStmt, f.Type))
+
+				break
+			}
+		}
+
+		return errs
+	}
+
+	if columns := countSelectColumns(stmt); columns > 0 {
+		if scalars := countScalarOuts(outTypes); scalars > 0 && scalars != columns {
+			errs = append(errs, fmt.Errorf("[%s] sql %s selects %d columns, but the func %v returns %d values",
+				p.ID, p.RawStmt, columns, f.Type, scalars))
+		}
+	}
+
+	return errs
+}
+
+// validateScript validates each statement of the evaluated script.
+// nolint:goerr113
+func (p *SQLParsed) validateScript(script string, bean reflect.Value, evaluable bool) MultiError {
+	var errs MultiError
+
+	for _, stmt := range splitScript(script, sqlDelimiter(p.SQL)) {
+		sp := *p
+		if err := sp.parseSQL(stmt); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if _, err := sqlparser.Parse(sp.runSQL); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] failed to parse sql %s error %w", p.ID, stmt, err))
+			continue
+		}
+
+		bindBy, _, err := parseBindBy(sp.ID, sp.Vars)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if sp.BindBy = bindBy; sp.isBindBy(ByName) && bean.IsValid() && evaluable {
+			if _, err := sp.createNamedVars(bean); err != nil {
+				errs = append(errs, fmt.Errorf("[%s] %w", p.ID, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+// sampleEnv creates the env with zero values of the func's arguments for the dynamic SQL evaluating.
+// The slices have one zero element, so that the for parts are evaluated once.
+func (p *SQLParsed) sampleEnv(numIn int, f StructField) (env map[string]interface{}, bean reflect.Value, evaluable bool) {
+	offset := f.Type.NumIn() - numIn // skip the leading context.Context argument
+	args := make([]reflect.Value, numIn)
+
+	for i := 0; i < numIn; i++ {
+		args[i] = sampleValue(reflect.New(f.Type.In(i + offset)).Elem())
+	}
+
+	if !p.isBindBy(ByName) {
+		env = make(map[string]interface{})
+		for i, arg := range args {
+			env[fmt.Sprintf("_%d", i+1)] = arg.Interface()
+		}
+
+		return env, reflect.Value{}, true
+	}
+
+	if numIn > 0 {
+		bean = namedBean(numIn, f, args)
+		if bean.Kind() == reflect.Slice {
+			bean = reflect.New(bean.Type().Elem()).Elem()
+		}
+	}
+
+	// the keys of the map arguments are unknown, but the map built from the multiple arguments.
+	evaluable = true
+
+	for i := 0; i < numIn; i++ {
+		if t := indirectType(f.Type.In(i + offset)); t.Kind() == reflect.Map ||
+			t.Kind() == reflect.Slice && indirectType(t.Elem()).Kind() == reflect.Map {
+			evaluable = false
+		}
+	}
+
+	return p.createNamedMap(bean), bean, evaluable
+}
+
+// sampleValue sets the slice, or the slice fields of the struct, to one zero element.
+func sampleValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() && f.Kind() == reflect.Slice {
+				sampleValue(f)
+			}
+		}
+	}
+
+	return v
+}
+
+func countSelectColumns(stmt sqlparser.Statement) int {
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return 0
+	}
+
+	for _, expr := range sel.SelectExprs {
+		if _, star := expr.(*sqlparser.StarExpr); star {
+			return 0
+		}
+	}
+
+	return len(sel.SelectExprs)
+}
+
+// countScalarOuts counts the scalar returns, 0 for struct or map returns.
+func countScalarOuts(outTypes []reflect.Type) int {
+	if len(outTypes) == 0 {
+		return 0
+	}
+
+	t := outTypes[0]
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		if t != timeType && !ImplSQLScanner(reflect.PtrTo(t)) {
+			return 0
+		}
+	}
+
+	return len(outTypes)
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package sqlx_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type strictDao struct {
+	CreateTable func()                          `sql:"create table person(id varchar(100), age int)"`
+	Find        func(id string) person          `sql:"select id, age from person where id=:1"`
+	ListAll     func(queryCond) []person        `sql:"select id, age from person"`
+	FindByAge   func(int) (string, int)         `sql:"select id, age from person where 1=1 /* if _1 > 0 */ and age=:1 /* end */"`
+	NoArgs      func() person                   `sql:"select id, age from person where id=:1"`
+	BadName     func(person) []person           `sql:"select id, age from person where id=:xid"`
+	BadParse    func(string) person             `sql:"select id, age form person where id=:1"`
+	BadColumns  func(string) (string, int, int) `sql:"select id, age from person where id=:1"`
+	BadExec     func(string) string             `sql:"delete from person where id=:1"`
+	BadExpr     func(int) []person              `sql:"select id, age from person /* if _1 > 'a' */ where age=:1 /* end */"`
+}
+
+func TestStrict(t *testing.T) {
+	that := assert.New(t)
+
+	that.Nil(sqlx.CreateDao(&personDao{}, sqlx.WithDB(openDB(t)), sqlx.WithStrict()))
+
+	err := sqlx.CreateDao(&strictDao{}, sqlx.WithDB(openDB(t)), sqlx.WithStrict())
+	that.Error(err)
+
+	errs, ok := err.(sqlx.MultiError)
+	that.True(ok)
+	that.Len(errs, 6)
+	that.Contains(errs[0].Error(), "[NoArgs]")
+	that.Contains(errs[1].Error(), "named var xid not found")
+	that.Contains(errs[2].Error(), "[BadParse] failed to parse sql")
+	that.Contains(errs[3].Error(), "selects 2 columns")
+	that.Contains(errs[4].Error(), "requires integer returns")
+	that.Contains(errs[5].Error(), "[BadExpr] failed to eval sql")
+
+	// not strict, CreateDao does not detect the problems in advance.
+	that.Nil(sqlx.CreateDao(&strictDao{}, sqlx.WithDB(openDB(t))))
+}
+
+func TestStrictErrorsIs(t *testing.T) {
+	that := assert.New(t)
+
+	// the bad tag is built at runtime to avoid the complaint of go vet.
+	badTagDao := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "Find", Type: reflect.TypeOf(func() []person { return nil }), Tag: `sql:"select id, age from person`,
+	}})).Interface()
+
+	err := sqlx.CreateDao(badTagDao, sqlx.WithDB(openDB(t)))
+	that.Equal(sqlx.ErrTagValueSyntax, err)
+
+	err = sqlx.CreateDao(badTagDao, sqlx.WithDB(openDB(t)), sqlx.WithStrict())
+	that.True(errors.Is(err, sqlx.ErrTagValueSyntax))
+
+	var errs sqlx.MultiError
+	that.True(errors.As(err, &errs))
+	that.Len(errs, 1)
+}
+
+type strictScriptDao struct {
+	Move      func(person)                `sql:"delete from person where id = :id; insert into person(id, age) values(:id, :age)"`
+	BadScript func(person)                `sql:"delete form person where id = :id; insert into person(id, age) values(:id, :age)"`
+	BadName   func(person)                `sql:"delete from person where id = :id; insert into person(id, age) values(:id, :xage)"`
+	ArgsOK    func(string, int) int       `sql:"select count(*) from person where id = :id and age = :age" args:"id,age"`
+	BadArgs   func(string, int) int       `sql:"select count(*) from person where id = :id and age = :xage" args:"id,age"`
+	MapArg    func(map[string]string) int `sql:"select count(*) from person where id = :id"`
+}
+
+type strictLogger struct {
+	sqlx.DaoLoggerNoop
+	errs []error
+}
+
+func (l *strictLogger) LogError(err error) { l.errs = append(l.errs, err) }
+
+func TestStrictScriptsAndArgs(t *testing.T) {
+	that := assert.New(t)
+
+	logger := &strictLogger{}
+	err := sqlx.CreateDao(&strictScriptDao{}, sqlx.WithDB(openDB(t)), sqlx.WithStrict(), sqlx.WithLogger(logger))
+	that.Error(err)
+
+	errs, ok := err.(sqlx.MultiError)
+	that.True(ok)
+	that.Len(errs, 3)
+	that.Contains(errs[0].Error(), "[BadScript] failed to parse sql")
+	that.Contains(errs[1].Error(), "[BadName] named var xage not found")
+	that.Contains(errs[2].Error(), "[BadArgs] named var xage not found")
+
+	// the keys of the map argument are unknown in advance.
+	that.Len(logger.errs, 1)
+	that.Contains(logger.errs[0].Error(), "[MapArg]")
+	that.Contains(logger.errs[0].Error(), "unvalidated")
+}
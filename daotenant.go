@@ -0,0 +1,296 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bingoohuang/sqlparser/sqlparser"
+)
+
+// ErrNoTenant is the error when the SQL touches the tenant tables, but no tenant found in the context.
+var ErrNoTenant = errors.New("no tenant in the context")
+
+type tenantIDKey struct{}
+
+// WithTenantID returns a copy of the ctx which carries the tenant ID for the TenantRewriter.
+func WithTenantID(ctx context.Context, tenantID interface{}) context.Context {
+	return context.WithValue(ctx, tenantIDKey{}, tenantID)
+}
+
+// TenantID returns the tenant ID carried by the ctx.
+func TenantID(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	v := ctx.Value(tenantIDKey{})
+
+	return v, v != nil
+}
+
+// TenantRewriter rewrites the SQL for the multi-tenancy, it adds the tenant predicate like tenant_id = ?
+// to the WHERE of every SELECT, UPDATE and DELETE touching the tenant tables,
+// and fills the tenant column in the INSERT column lists.
+// The tenant value is taken from the context by TenantID, and is bound to the added bind marks,
+// so that the same SQL is shared by all the tenants, like in the prepared statements cache.
+type TenantRewriter struct {
+	// Column is the tenant column name, like tenant_id.
+	Column string
+
+	tables map[string]bool
+}
+
+// NewTenantRewriter creates a TenantRewriter for the tenant column and the tenant tables.
+func NewTenantRewriter(column string, tables ...string) *TenantRewriter {
+	t := &TenantRewriter{Column: column, tables: make(map[string]bool, len(tables))}
+	for _, table := range tables {
+		t.tables[strings.ToLower(table)] = true
+	}
+
+	return t
+}
+
+// WithTenant specifies the TenantRewriter to inject the tenant predicates into the SQL.
+func WithTenant(rewriter *TenantRewriter) CreateDaoOpter {
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.Tenant = rewriter })
+}
+
+// Rewrite rewrites the query with the ? bind marks and its args with the tenant in the ctx,
+// it fails with ErrNoTenant when the query touches the tenant tables but no tenant found in the ctx.
+func (t *TenantRewriter) Rewrite(ctx context.Context, query string, args ...interface{}) (string, []interface{}, error) {
+	stmt, err := sqlparser.Parse(numberBindMarks(query))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse sql %s for tenant error %w", query, err)
+	}
+
+	if stmt, err = t.RewriteQuery(ctx, stmt); err != nil {
+		return "", nil, err
+	}
+
+	tenantID, _ := TenantID(ctx)
+	query, args = bindStmt(stmt, args, tenantID)
+
+	return query, args, nil
+}
+
+// tenantArg is the bind mark of the tenant added by the TenantRewriter,
+// which is bound to the tenant ID when the statement is formatted.
+const tenantArg = ":sqlx_tenant"
+
+// RewriteQuery rewrites the parsed SQL statement with the tenant in the ctx,
+// the tenant is added as the bind mark, which is bound to the tenant ID by Rewrite or the dao.
+func (t *TenantRewriter) RewriteQuery(ctx context.Context, stmt sqlparser.Statement) (sqlparser.Statement, error) {
+	r := &tenantRewrite{TenantRewriter: t}
+
+	if _, ok := TenantID(ctx); ok {
+		r.val = sqlparser.NewValArg([]byte(tenantArg))
+	}
+
+	if err := sqlparser.Walk(r.visit, stmt); err != nil {
+		return nil, err
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return stmt, nil
+}
+
+type tenantRewrite struct {
+	*TenantRewriter
+
+	val *sqlparser.SQLVal
+	err error
+}
+
+func (r *tenantRewrite) visit(node sqlparser.SQLNode) (bool, error) {
+	switch n := node.(type) {
+	case *sqlparser.Select:
+		n.Where = r.where(n.Where, r.filter(n.From))
+	case *sqlparser.Update:
+		n.Where = r.where(n.Where, r.filter(n.TableExprs))
+	case *sqlparser.Delete:
+		n.Where = r.where(n.Where, r.filter(n.TableExprs))
+	case *sqlparser.Insert:
+		if err := r.insert(n); err != nil {
+			return false, err
+		}
+	}
+
+	return r.err == nil, nil
+}
+
+// filter creates the tenant predicates of the tenant tables in the table exprs.
+// The predicates of the outer joined tables are put into the ON conditions to keep the outer join semantics,
+// and the others are returned for the WHERE.
+func (r *tenantRewrite) filter(exprs sqlparser.TableExprs) []sqlparser.Expr {
+	qualify := countTables(exprs) > 1
+
+	var preds []sqlparser.Expr
+
+	for _, expr := range exprs {
+		preds = append(preds, r.filterTable(expr, qualify)...)
+	}
+
+	return preds
+}
+
+func (r *tenantRewrite) filterTable(expr sqlparser.TableExpr, qualify bool) []sqlparser.Expr {
+	switch e := expr.(type) {
+	case *sqlparser.AliasedTableExpr:
+		name, ok := e.Expr.(sqlparser.TableName)
+		if !ok || !r.tables[strings.ToLower(name.Name.String())] {
+			return nil
+		}
+
+		if !e.As.IsEmpty() {
+			name = sqlparser.TableName{Name: e.As}
+		}
+
+		return []sqlparser.Expr{r.predicate(name, qualify)}
+	case *sqlparser.ParenTableExpr:
+		var preds []sqlparser.Expr
+		for _, sub := range e.Exprs {
+			preds = append(preds, r.filterTable(sub, qualify)...)
+		}
+
+		return preds
+	case *sqlparser.JoinTableExpr:
+		left := r.filterTable(e.LeftExpr, qualify)
+		right := r.filterTable(e.RightExpr, qualify)
+
+		switch {
+		case e.Join == sqlparser.LeftJoinStr && e.On != nil:
+			e.On = andExprs(e.On, right)
+			return left
+		case e.Join == sqlparser.RightJoinStr && e.On != nil:
+			e.On = andExprs(e.On, left)
+			return right
+		default:
+			return append(left, right...)
+		}
+	}
+
+	return nil
+}
+
+// value returns the tenant value for the tenant table, nil when no tenant found.
+func (r *tenantRewrite) value(table sqlparser.TableName) sqlparser.Expr {
+	if r.val == nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("tenant table %s: %w", sqlparser.String(table), ErrNoTenant)
+		}
+
+		return nil
+	}
+
+	return r.val
+}
+
+func (r *tenantRewrite) predicate(table sqlparser.TableName, qualify bool) sqlparser.Expr {
+	val := r.value(table)
+	if val == nil {
+		return nil
+	}
+
+	col := &sqlparser.ColName{Name: sqlparser.NewColIdent(r.Column)}
+	if qualify {
+		col.Qualifier = table
+	}
+
+	return &sqlparser.ComparisonExpr{Operator: sqlparser.EqualStr, Left: col, Right: val}
+}
+
+func (r *tenantRewrite) where(where *sqlparser.Where, preds []sqlparser.Expr) *sqlparser.Where {
+	if len(preds) == 0 {
+		return where
+	}
+
+	if where == nil {
+		return sqlparser.NewWhere(sqlparser.WhereStr, andExprs(nil, preds))
+	}
+
+	where.Expr = andExprs(where.Expr, preds)
+
+	return where
+}
+
+// nolint:goerr113
+func (r *tenantRewrite) insert(n *sqlparser.Insert) error {
+	if !r.tables[strings.ToLower(n.Table.Name.String())] {
+		return nil
+	}
+
+	for _, col := range n.Columns {
+		if col.EqualString(r.Column) {
+			return fmt.Errorf("insert into tenant table %s with the tenant column %s is not allowed",
+				sqlparser.String(n.Table), r.Column)
+		}
+	}
+
+	if len(n.Columns) == 0 {
+		return fmt.Errorf("insert into tenant table %s requires the column list", sqlparser.String(n.Table))
+	}
+
+	val := r.value(n.Table)
+	if val == nil {
+		return r.err
+	}
+
+	switch rows := n.Rows.(type) {
+	case sqlparser.Values:
+		for i := range rows {
+			rows[i] = append(rows[i], val)
+		}
+	case *sqlparser.Select:
+		rows.SelectExprs = append(rows.SelectExprs, &sqlparser.AliasedExpr{Expr: val})
+	default:
+		return fmt.Errorf("unsupported insert rows %s for tenant table %s",
+			sqlparser.String(n.Rows), sqlparser.String(n.Table))
+	}
+
+	n.Columns = append(n.Columns, sqlparser.NewColIdent(r.Column))
+
+	return nil
+}
+
+// andExprs ands the expr with the predicates.
+func andExprs(expr sqlparser.Expr, preds []sqlparser.Expr) sqlparser.Expr {
+	if _, ok := expr.(*sqlparser.OrExpr); ok {
+		expr = &sqlparser.ParenExpr{Expr: expr}
+	}
+
+	for _, pred := range preds {
+		if pred == nil {
+			continue
+		}
+
+		if expr == nil {
+			expr = pred
+		} else {
+			expr = &sqlparser.AndExpr{Left: expr, Right: pred}
+		}
+	}
+
+	return expr
+}
+
+func countTables(exprs sqlparser.TableExprs) int {
+	n := 0
+
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case *sqlparser.AliasedTableExpr:
+			n++
+		case *sqlparser.ParenTableExpr:
+			n += countTables(e.Exprs)
+		case *sqlparser.JoinTableExpr:
+			n += countTables(sqlparser.TableExprs{e.LeftExpr, e.RightExpr})
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,100 @@
+package sqlx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantRewrite(t *testing.T) {
+	that := assert.New(t)
+	rw := sqlx.NewTenantRewriter("tenant_id", "person", "orders")
+	ctx := sqlx.WithTenantID(context.Background(), "acme")
+
+	cases := []struct {
+		sql          string
+		args         []interface{}
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{"select id from person", nil, "select id from person where tenant_id = ?", []interface{}{"acme"}},
+		{"select id from person where age > ? or id = ?", []interface{}{10, "100"},
+			"select id from person where (age > ? or id = ?) and tenant_id = ?", []interface{}{10, "100", "acme"}},
+		{"select p.id from person p left join orders o on p.id = o.pid where p.age > ?", []interface{}{10},
+			"select p.id from person as p left join orders as o on p.id = o.pid and o.tenant_id = ? " +
+				"where p.age > ? and p.tenant_id = ?", []interface{}{"acme", 10, "acme"}},
+		{"select id from dept where id in (select dept from person) and id > ?", []interface{}{1},
+			"select id from dept where id in (select dept from person where tenant_id = ?) and id > ?",
+			[]interface{}{"acme", 1}},
+		{"update person set age = ? where id = ?", []interface{}{20, "100"},
+			"update person set age = ? where id = ? and tenant_id = ?", []interface{}{20, "100", "acme"}},
+		{"delete from person", nil, "delete from person where tenant_id = ?", []interface{}{"acme"}},
+		{"insert into person(id, age) values (?, ?), (?, ?)", []interface{}{"1", 1, "2", 2},
+			"insert into person(id, age, tenant_id) values (?, ?, ?), (?, ?, ?)",
+			[]interface{}{"1", 1, "acme", "2", 2, "acme"}},
+		{"select id from dept where id = ?", []interface{}{1}, "select id from dept where id = ?", []interface{}{1}},
+	}
+
+	for _, c := range cases {
+		rewritten, args, err := rw.Rewrite(ctx, c.sql, c.args...)
+		that.Nil(err)
+		that.Equal(c.expected, rewritten)
+		that.Equal(c.expectedArgs, args)
+	}
+
+	_, _, err := rw.Rewrite(context.Background(), "select id from person")
+	that.True(errors.Is(err, sqlx.ErrNoTenant))
+
+	_, _, err = rw.Rewrite(context.Background(), "insert into person(id) values(?)", "1")
+	that.True(errors.Is(err, sqlx.ErrNoTenant))
+
+	_, _, err = rw.Rewrite(ctx, "insert into person(id, tenant_id) values(?, ?)", "1", "acme")
+	that.NotNil(err)
+
+	sql, _, err := rw.Rewrite(context.Background(), "select id from dept")
+	that.Nil(err)
+	that.Equal("select id from dept", sql)
+}
+
+type tenantDao struct {
+	CreateTable func()                                     `sql:"create table person(id varchar(100), age int, tenant_id varchar(10))"`
+	Add         func(context.Context, person) error        `sql:"insert into person(id, age) values(:id, :age)"`
+	Find        func(context.Context) ([]person, error)    `sql:"select id, age from person order by id"`
+	Delete      func(context.Context, string) (int, error) `sql:"delete from person where id = :1"`
+	FindAll     func() ([]person, error)                   `sql:"select id, age from person order by id"`
+}
+
+func TestTenantDao(t *testing.T) {
+	that := assert.New(t)
+	dao := &tenantDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithTenant(sqlx.NewTenantRewriter("tenant_id", "person"))))
+
+	dao.CreateTable()
+
+	acme := sqlx.WithTenantID(context.Background(), "acme")
+	umbrella := sqlx.WithTenantID(context.Background(), "umbrella")
+
+	that.Nil(dao.Add(acme, person{ID: "100", Age: 10}))
+	that.Nil(dao.Add(acme, person{ID: "200", Age: 20}))
+	that.Nil(dao.Add(umbrella, person{ID: "300", Age: 30}))
+
+	ps, err := dao.Find(acme)
+	that.Nil(err)
+	that.Equal([]person{{ID: "100", Age: 10}, {ID: "200", Age: 20}}, ps)
+
+	n, err := dao.Delete(umbrella, "100")
+	that.Nil(err)
+	that.Equal(0, n)
+
+	ps, err = dao.Find(umbrella)
+	that.Nil(err)
+	that.Equal([]person{{ID: "300", Age: 30}}, ps)
+
+	_, err = dao.FindAll()
+	that.True(errors.Is(err, sqlx.ErrNoTenant))
+
+	that.True(errors.Is(dao.Add(context.Background(), person{ID: "400"}), sqlx.ErrNoTenant))
+}
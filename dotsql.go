@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -332,6 +334,110 @@ func (p *IfPart) Raw() string {
 	return raw
 }
 
+// ForPart is the part that has the format of for item, i in items sep: "," ... end,
+// which repeats the part for each element of the items slice.
+// The bind variables of the item and the index like :item.name, :i in the part are rewritten
+// to :items.0.name, 0 for each element, so that the iterations do not collide.
+type ForPart struct {
+	Item          string
+	Index         string
+	Items         string
+	Sep           string
+	CompiledItems *vm.Program
+	Part          SQLPart
+}
+
+// Compile compiles the items expression and the repeated part in advance.
+func (p *ForPart) Compile() (err error) {
+	if p.CompiledItems, err = expr.Compile(p.Items); err != nil {
+		return err
+	}
+
+	return p.Part.Compile()
+}
+
+// Eval evaluates the SQL part to a real SQL.
+func (p *ForPart) Eval(env map[string]interface{}) (string, error) {
+	output, err := expr.Run(p.CompiledItems, env)
+	if err != nil {
+		return "", err
+	}
+
+	items := indirectValue(reflect.ValueOf(output))
+	if !items.IsValid() {
+		return "", nil
+	}
+
+	if k := items.Kind(); k != reflect.Slice && k != reflect.Array {
+		return "", fmt.Errorf("%s is not a slice, but %v", p.Items, items.Type()) // nolint:goerr113
+	}
+
+	iterEnv := make(map[string]interface{}, len(env)+2)
+	for k, v := range env {
+		iterEnv[k] = v
+	}
+
+	values := make([]string, 0, items.Len())
+
+	for i := 0; i < items.Len(); i++ {
+		iterEnv[p.Item] = items.Index(i).Interface()
+		if p.Index != "" {
+			iterEnv[p.Index] = i
+		}
+
+		value, err := p.Part.Eval(iterEnv)
+		if err != nil {
+			return "", err
+		}
+
+		if value != "" {
+			values = append(values, p.rewriteVars(value, i))
+		}
+	}
+
+	return strings.Join(values, p.Sep), nil
+}
+
+// rewriteVars rewrites the bind variables of the item and the index for the i-th iteration.
+func (p *ForPart) rewriteVars(s string, i int) string {
+	return sqlre.ReplaceAllStringFunc(s, func(v string) string {
+		lead, trail := "", ""
+		if strings.HasPrefix(v, "'") {
+			lead, v = "'", v[1:]
+		}
+
+		if len(v) > 1 && strings.HasSuffix(v, "'") {
+			trail, v = "'", v[:len(v)-1]
+		}
+
+		name := v[1:]
+		head, rest := name, ""
+
+		if pos := strings.Index(name, "."); pos >= 0 {
+			head, rest = name[:pos], name[pos:]
+		}
+
+		switch {
+		case head == p.Item:
+			return lead + ":" + p.Items + "." + strconv.Itoa(i) + rest + trail
+		case head == p.Index && rest == "":
+			return lead + strconv.Itoa(i) + trail
+		default:
+			return lead + v + trail
+		}
+	})
+}
+
+// Raw returns the raw content.
+func (p *ForPart) Raw() string {
+	header := p.Item
+	if p.Index != "" {
+		header += ", " + p.Index
+	}
+
+	return header + " in " + p.Items + "\n" + p.Part.Raw()
+}
+
 // MultiPart is the multi SQLParts.
 type MultiPart struct {
 	Parts []SQLPart
@@ -395,6 +501,7 @@ func (p *MultiPart) Compile() error {
 
 var _ SQLPart = (*LiteralPart)(nil)
 var _ SQLPart = (*IfPart)(nil)
+var _ SQLPart = (*ForPart)(nil)
 var _ SQLPart = (*MultiPart)(nil)
 var _ SQLPart = (*PostProcessingSQLPart)(nil)
 
@@ -596,18 +703,77 @@ func (p *IfSQLPartParser) Parse(lines []string) (partLines int, part SQLPart, er
 	return 0, nil, fmt.Errorf("no end found for if expr") // nolint:goerr113
 }
 
+// ForSQLPartParser defines the Parser of ForPart.
+type ForSQLPartParser struct {
+	Header string
+}
+
+// MakeForSQLPartParser makes a ForSQLPartParser.
+func MakeForSQLPartParser(header string) *ForSQLPartParser {
+	return &ForSQLPartParser{Header: header}
+}
+
+// forHeaderRe matches the for header like item, i in items sep: ",".
+var forHeaderRe = regexp.MustCompile(`^(\w+)(?:\s*,\s*(\w+))?\s+in\s+(\w+(?:\.\w+)*)(?:\s+sep\s*:\s*(.+))?$`)
+
+// Parse parses the lines to SQLPart.
+// nolint:goerr113
+func (p *ForSQLPartParser) Parse(lines []string) (partLines int, part SQLPart, err error) {
+	subs := forHeaderRe.FindStringSubmatch(p.Header)
+	if subs == nil {
+		return 0, nil, fmt.Errorf("bad for expr %q, like: for item, i in items sep: \",\"", p.Header)
+	}
+
+	forPart := &ForPart{Item: subs[1], Index: subs[2], Items: subs[3], Sep: " "}
+
+	if sep := subs[4]; sep != "" {
+		if forPart.Sep, err = unquoteSep(sep); err != nil {
+			return 0, nil, fmt.Errorf("bad sep %s in for expr %q", sep, p.Header)
+		}
+	}
+
+	processLines, sqlPart, err := ParseDynamicSQL(lines, "end")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if processLines >= len(lines) {
+		return 0, nil, fmt.Errorf("no end found for for expr %q", p.Header)
+	}
+
+	forPart.Part = sqlPart
+
+	return processLines + 2 /*包括for 行*/, forPart, nil
+}
+
+func unquoteSep(sep string) (string, error) {
+	if len(sep) >= 2 && sep[0] == '\'' && sep[len(sep)-1] == '\'' {
+		return sep[1 : len(sep)-1], nil
+	}
+
+	if strings.HasPrefix(sep, `"`) {
+		return strconv.Unquote(sep)
+	}
+
+	return sep, nil
+}
+
 // MakeLiteralMultiPart makes a MultiPart.
 func MakeLiteralMultiPart(l string) *MultiPart {
 	return &MultiPart{Parts: []SQLPart{&LiteralPart{l}}}
 }
 
 var _ SQLPartParser = (*IfSQLPartParser)(nil)
+var _ SQLPartParser = (*ForSQLPartParser)(nil)
 
 // CreateParser creates a SQLPartParser.
 // If no parser found, nil returned.
 func CreateParser(word string, l string) SQLPartParser {
-	if word == "if" {
+	switch word {
+	case "if":
 		return MakeIfSQLPartParser(l)
+	case "for":
+		return MakeForSQLPartParser(l)
 	}
 
 	return nil
@@ -0,0 +1,104 @@
+package sqlx_test
+
+import (
+	"testing"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDynamicSQLFor(t *testing.T) {
+	that := assert.New(t)
+
+	lines, part, err := sqlx.ParseDynamicSQL([]string{
+		"insert into person(id, age) values",
+		`-- for p, i in persons sep: ","`,
+		"(:p.id, :p.age)",
+		"-- end"})
+	that.Nil(err)
+	that.Equal(4, lines)
+	that.Nil(part.Compile())
+
+	env := map[string]interface{}{"persons": []interface{}{
+		map[string]interface{}{"id": "a", "age": 10},
+		map[string]interface{}{"id": "b", "age": 20},
+	}}
+	sql, err := part.Eval(env)
+	that.Nil(err)
+	that.Equal("insert into person(id, age) values (:persons.0.id, :persons.0.age),(:persons.1.id, :persons.1.age)", sql)
+
+	_, part, err = sqlx.ParseDynamicSQL([]string{
+		"select id from person where 1 = 1",
+		"-- for p, i in persons",
+		"-- if p.age > 10",
+		"or (id = :p.id and :i = :i)",
+		"-- end",
+		"-- end"})
+	that.Nil(err)
+	that.Nil(part.Compile())
+
+	sql, err = part.Eval(env)
+	that.Nil(err)
+	that.Equal("select id from person where 1 = 1 or (id = :persons.1.id and 1 = 1)", sql)
+
+	sql, err = part.Eval(map[string]interface{}{"persons": nil})
+	that.Nil(err)
+	that.Equal("select id from person where 1 = 1 ", sql)
+
+	_, _, err = sqlx.ParseDynamicSQL([]string{"-- for p in persons", "(:p.id)"})
+	that.NotNil(err)
+
+	_, _, err = sqlx.ParseDynamicSQL([]string{"-- for persons", "(:p.id)", "-- end"})
+	that.NotNil(err)
+}
+
+const dotSQLFor = `
+-- name: CreateTable
+create table person(id varchar(100), age int);
+
+-- name: AddAll
+insert into person(id, age) values
+-- for p in persons sep: ","
+(:p.id, :p.age)
+-- end
+;
+
+-- name: Find
+select id, age from person where id in (
+-- for id, i in ids sep: ", "
+:id
+-- end
+) order by id;
+`
+
+type personsBean struct {
+	Persons []person
+}
+
+type idsBean struct {
+	IDs []string `name:"ids"`
+}
+
+type personForDao struct {
+	CreateTable func()
+	AddAll      func(personsBean) int
+	Find        func(idsBean) []person
+}
+
+func TestDaoFor(t *testing.T) {
+	that := assert.New(t)
+
+	dao := &personForDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithSQLStr(dotSQLFor)))
+
+	dao.CreateTable()
+	that.Equal(3, dao.AddAll(personsBean{Persons: []person{{"a", 10}, {"b", 20}, {"c", 30}}}))
+	that.Equal([]person{{"a", 10}, {"c", 30}}, dao.Find(idsBean{IDs: []string{"c", "a"}}))
+}
+
+func TestDaoForStrict(t *testing.T) {
+	that := assert.New(t)
+
+	// the for parts are validated with one zero element of the items.
+	that.Nil(sqlx.CreateDao(&personForDao{}, sqlx.WithDB(openDB(t)), sqlx.WithSQLStr(dotSQLFor), sqlx.WithStrict()))
+}
@@ -0,0 +1,255 @@
+package sqlx
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DotSQLWatcher watches the dotsql file by polling its modification time,
+// and reloads the SQL parts used by the dao funcs when the file changes.
+// The reload error is reported to the WithReloadError func, and the SQL parts loaded before keep working.
+// The bind mode and the statement kind of a dao func are fixed when the dao is created,
+// so the reloading which changes them of any SQL part is rejected.
+type DotSQLWatcher struct {
+	onError func(err error)
+
+	file     string
+	interval time.Duration
+
+	mu      sync.RWMutex
+	parts   map[string]SQLPart
+	modTime time.Time
+	size    int64
+	lastErr error
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// DotSQLWatcherOpt is the option of the DotSQLWatcher.
+type DotSQLWatcherOpt func(w *DotSQLWatcher)
+
+// WithReloadError specifies the func called when the reloading fails, log.Printf by default.
+func WithReloadError(onError func(err error)) DotSQLWatcherOpt {
+	return func(w *DotSQLWatcher) { w.onError = onError }
+}
+
+// NewDotSQLWatcher loads the dotsql file and starts watching it at the interval.
+func NewDotSQLWatcher(file string, interval time.Duration, opts ...DotSQLWatcherOpt) (*DotSQLWatcher, error) {
+	w := &DotSQLWatcher{
+		onError: func(err error) { log.Printf("E! error: %v", err) },
+
+		file:     file,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.watch()
+
+	return w, nil
+}
+
+// WithDotSQLWatcher imports SQL queries from the file watched by the DotSQLWatcher.
+func WithDotSQLWatcher(w *DotSQLWatcher) CreateDaoOpter {
+	return CreateDaoOptFn(func(opt *CreateDaoOpt) { opt.DotSQL = w.Raw })
+}
+
+// Raw returns the SQLPart by name, which evaluates the latest SQL loaded.
+func (w *DotSQLWatcher) Raw(name string) (SQLPart, error) {
+	if _, err := w.part(name); err != nil {
+		return nil, err
+	}
+
+	return &watchedPart{w: w, name: name}, nil
+}
+
+// Err returns the error of the last reloading, nil when succeeded.
+func (w *DotSQLWatcher) Err() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.lastErr
+}
+
+// Close stops watching.
+func (w *DotSQLWatcher) Close() error {
+	w.once.Do(func() { close(w.stop) })
+	return nil
+}
+
+func (w *DotSQLWatcher) watch() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+
+			if err := w.reload(); err != nil && w.onError != nil {
+				w.onError(err)
+			}
+		}
+	}
+}
+
+func (w *DotSQLWatcher) changed() bool {
+	fi, err := os.Stat(w.file)
+	if err != nil {
+		return false
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return !fi.ModTime().Equal(w.modTime) || fi.Size() != w.size
+}
+
+// reload parses the file and swaps the SQL parts when all of them are parsed successfully.
+func (w *DotSQLWatcher) reload() error {
+	fi, err := os.Stat(w.file)
+	if err != nil {
+		return w.setErr(fmt.Errorf("failed to stat dotsql %s error %w", w.file, err))
+	}
+
+	ds, err := DotSQLLoadFile(w.file)
+	if err != nil {
+		return w.setErr(fmt.Errorf("failed to load dotsql %s error %w", w.file, err))
+	}
+
+	parts := make(map[string]SQLPart, len(ds.Sqls))
+
+	for name, item := range ds.Sqls {
+		part, err := item.DynamicSQL()
+		if err != nil {
+			return w.reject(fi, fmt.Errorf("failed to parse dotsql %s of %s error %w", name, w.file, err))
+		}
+
+		if err := w.checkShape(name, part); err != nil {
+			return w.reject(fi, fmt.Errorf("failed to reload dotsql %s of %s error %w", name, w.file, err))
+		}
+
+		parts[name] = part
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.parts, w.modTime, w.size, w.lastErr = parts, fi.ModTime(), fi.Size(), nil
+
+	return nil
+}
+
+// reject records the file info to avoid reloading the broken file repeatedly, and the error.
+func (w *DotSQLWatcher) reject(fi os.FileInfo, err error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.modTime, w.size, w.lastErr = fi.ModTime(), fi.Size(), err
+
+	return err
+}
+
+// partShape is the shape of the SQL part, which is fixed when the dao func is created.
+type partShape struct {
+	bindBy  bindBy
+	maxSeq  int
+	isQuery bool
+	script  bool
+}
+
+func makePartShape(name string, part SQLPart) (partShape, error) {
+	p, err := ParseSQL(name, part.Raw())
+	if err != nil {
+		return partShape{}, err
+	}
+
+	script := len(splitScript(part.Raw(), sqlDelimiter(part))) > 1
+
+	return partShape{bindBy: p.BindBy, maxSeq: p.MaxSeq, isQuery: p.IsQuery && !script, script: script}, nil
+}
+
+// checkShape checks the reloaded part keeps the shape of the part loaded before.
+// nolint:goerr113
+func (w *DotSQLWatcher) checkShape(name string, part SQLPart) error {
+	shape, err := makePartShape(name, part)
+	if err != nil {
+		return err
+	}
+
+	old, err := w.part(name)
+	if err != nil {
+		return nil // the new part or the initial loading.
+	}
+
+	if oldShape, err := makePartShape(name, old); err == nil && oldShape != shape {
+		return fmt.Errorf("the bind mode or the statement kind changed from %+v to %+v", oldShape, shape)
+	}
+
+	return nil
+}
+
+func (w *DotSQLWatcher) setErr(err error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastErr = err
+
+	return err
+}
+
+func (w *DotSQLWatcher) part(name string) (SQLPart, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	part, ok := w.parts[name]
+	if !ok {
+		return nil, fmt.Errorf("dotsql: '%s' could not be found", name) // nolint:goerr113
+	}
+
+	return part, nil
+}
+
+// watchedPart is the SQLPart which evaluates the latest SQL part loaded by the DotSQLWatcher.
+type watchedPart struct {
+	w    *DotSQLWatcher
+	name string
+}
+
+// Compile does nothing, the parts loaded by the watcher are compiled already.
+func (p *watchedPart) Compile() error { return nil }
+
+// Eval evaluates the SQL part to a real SQL.
+func (p *watchedPart) Eval(env map[string]interface{}) (string, error) {
+	part, err := p.w.part(p.name)
+	if err != nil {
+		return "", err
+	}
+
+	return part.Eval(env)
+}
+
+// Raw returns the raw content.
+func (p *watchedPart) Raw() string {
+	part, err := p.w.part(p.name)
+	if err != nil {
+		return ""
+	}
+
+	return part.Raw()
+}
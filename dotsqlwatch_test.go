@@ -0,0 +1,88 @@
+package sqlx_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type watchDao struct {
+	CreateTable func()
+	Add         func(person)
+	Find        func(string) []int
+}
+
+// writeDotSQL replaces the file by renaming, so the watcher never reads a partially written file.
+func writeDotSQL(t *testing.T, file, content string, modTime time.Time) {
+	tmp := file + ".tmp"
+	assert.Nil(t, ioutil.WriteFile(tmp, []byte(content), 0644))
+	assert.Nil(t, os.Chtimes(tmp, modTime, modTime))
+	assert.Nil(t, os.Rename(tmp, file))
+}
+
+func TestDotSQLWatcher(t *testing.T) {
+	that := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dotsql")
+	that.Nil(err)
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "dao.sql")
+	now := time.Now()
+	base := `
+-- name: CreateTable
+create table person(id varchar(100), age int);
+-- name: Add
+insert into person(id, age) values(:id, :age);
+`
+	writeDotSQL(t, file, base+"-- name: Find\nselect age from person where id = :1;\n", now.Add(-time.Hour))
+
+	reloadErrs := make(chan error, 10)
+	w, err := sqlx.NewDotSQLWatcher(file, 10*time.Millisecond,
+		sqlx.WithReloadError(func(err error) { reloadErrs <- err }))
+	that.Nil(err)
+
+	defer w.Close()
+
+	dao := &watchDao{}
+	that.Nil(sqlx.CreateDao(dao, sqlx.WithDB(openDB(t)), sqlx.WithDotSQLWatcher(w)))
+
+	dao.CreateTable()
+	dao.Add(person{ID: "10", Age: 10})
+	that.Equal([]int{10}, dao.Find("10"))
+
+	writeDotSQL(t, file, base+"-- name: Find\nselect age * 2 from person where id = :1;\n", now)
+	that.Eventually(func() bool { return len(dao.Find("10")) == 1 && dao.Find("10")[0] == 20 },
+		time.Second, 10*time.Millisecond)
+
+	writeDotSQL(t, file, base+"-- name: Find\nselect age from person where 1=1\n-- if _1 >>> \nand id = :1\n-- end\n",
+		now.Add(time.Hour))
+
+	select {
+	case err := <-reloadErrs:
+		that.Contains(err.Error(), "failed to parse dotsql Find")
+	case <-time.After(time.Second):
+		that.Fail("reload error expected")
+	}
+
+	that.Error(w.Err())
+	that.Equal([]int{20}, dao.Find("10")) // the last loaded SQL keeps working.
+
+	// the bind mode of the dao func is fixed, so the reloading changing it is rejected.
+	writeDotSQL(t, file, base+"-- name: Find\nselect age * 3 from person where id = :id;\n", now.Add(2*time.Hour))
+
+	select {
+	case err := <-reloadErrs:
+		that.Contains(err.Error(), "failed to reload dotsql Find")
+	case <-time.After(time.Second):
+		that.Fail("reload error expected")
+	}
+
+	that.Equal([]int{20}, dao.Find("10"))
+}
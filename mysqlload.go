@@ -0,0 +1,422 @@
+package sqlx
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bingoohuang/strcase"
+	"github.com/go-sql-driver/mysql"
+)
+
+// BulkLoadWarning is the warning reported by SHOW WARNINGS after the loading.
+type BulkLoadWarning struct {
+	Level   string
+	Code    int
+	Message string
+}
+
+// BulkLoadResult is the result of BulkLoad.
+type BulkLoadResult struct {
+	RowsAffected int64
+	Warnings     []BulkLoadWarning
+}
+
+// nolint:gochecknoglobals
+var bulkLoadSeq uint64
+
+// BulkLoad loads the beans into the MySQL table by LOAD DATA LOCAL INFILE, which is much faster
+// than the batched INSERTs for millions of rows.
+// The beans can be a slice, or a receiving channel as a stream, of the structs or the maps.
+// The columns are mapped by the name tags of the struct fields, or the snake case of the field names,
+// and the sorted keys of the first map. The struct fields tagged with json or encrypt are serialized
+// like the dao funcs, and the encrypt fields require the WithKeyProvider option.
+// The table can be qualified by the schema like db.person, and the names are quoted by the backticks.
+// The rows are serialized to TSV with \N for NULL, and streamed by the reader registered
+// by mysql.RegisterReaderHandler, so the DSN requires the allowAllFiles=true or the local_infile enabled.
+// The loading runs in a transaction, which is rolled back when the serializing fails mid-stream,
+// so that no partial rows are committed into the transactional tables, like InnoDB.
+func BulkLoad(ctx context.Context, db *sql.DB, table string, beans interface{},
+	opts ...CreateDaoOpter) (*BulkLoadResult, error) {
+	rows, err := newTSVRows(beans, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows.columns) == 0 {
+		return &BulkLoadResult{}, nil
+	}
+
+	// LOAD DATA and SHOW WARNINGS should be on the same connection, which the tx holds.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx %w", err)
+	}
+
+	r, err := bulkLoad(ctx, tx, table, rows)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bulk load error %w", err)
+	}
+
+	return r, nil
+}
+
+func bulkLoad(ctx context.Context, tx *sql.Tx, table string, rows *tsvRows) (*BulkLoadResult, error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+
+	go func() {
+		err := rows.write(ctx, pw)
+		_ = pw.CloseWithError(err)
+		done <- err
+	}()
+
+	name := fmt.Sprintf("sqlx_bulk_%d", atomic.AddUint64(&bulkLoadSeq, 1))
+	mysql.RegisterReaderHandler(name, func() io.Reader { return pr })
+
+	defer mysql.DeregisterReaderHandler(name)
+
+	query := "LOAD DATA LOCAL INFILE 'Reader::" + name + "' INTO TABLE " + quoteTable(table) +
+		` CHARACTER SET utf8mb4 FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (` +
+		strings.Join(rows.quotedColumns(), ", ") + ")"
+	result, err := tx.ExecContext(ctx, query)
+
+	_ = pr.Close() // stop the writing when the driver does not read all
+	writeErr := <-done
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s error %w", query, err)
+	}
+
+	if writeErr != nil && writeErr != io.ErrClosedPipe {
+		return nil, writeErr
+	}
+
+	r := &BulkLoadResult{}
+	if r.RowsAffected, err = result.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	if r.Warnings, err = showWarnings(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func showWarnings(ctx context.Context, tx *sql.Tx) ([]BulkLoadWarning, error) {
+	rows, err := tx.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, fmt.Errorf("failed to show warnings error %w", err)
+	}
+
+	defer rows.Close()
+
+	warnings := make([]BulkLoadWarning, 0)
+
+	for rows.Next() {
+		var w BulkLoadWarning
+		if err := rows.Scan(&w.Level, &w.Code, &w.Message); err != nil {
+			return nil, err
+		}
+
+		warnings = append(warnings, w)
+	}
+
+	return warnings, rows.Err()
+}
+
+// WriteTSV writes the beans, which is a slice or a receiving channel of the structs or the maps,
+// in the TSV format of LOAD DATA, and returns the columns, the opts are like BulkLoad.
+func WriteTSV(ctx context.Context, w io.Writer, beans interface{}, opts ...CreateDaoOpter) ([]string, error) {
+	rows, err := newTSVRows(beans, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return rows.columns, rows.write(ctx, w)
+}
+
+type tsvRows struct {
+	columns []string
+	next    func() (reflect.Value, bool)
+	values  func(bean reflect.Value) ([]interface{}, error)
+}
+
+// nolint:goerr113
+func newTSVRows(beans interface{}, opts []CreateDaoOpter) (*tsvRows, error) {
+	opt, err := applyCreateDaoOption(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bv := reflect.ValueOf(beans)
+	r := &tsvRows{}
+
+	switch bv.Kind() {
+	case reflect.Slice, reflect.Array:
+		i := 0
+		r.next = func() (reflect.Value, bool) {
+			if i >= bv.Len() {
+				return reflect.Value{}, false
+			}
+
+			i++
+
+			return bv.Index(i - 1), true
+		}
+	case reflect.Chan:
+		r.next = bv.Recv
+	default:
+		return nil, fmt.Errorf("beans should be a slice or a channel, but %T", beans)
+	}
+
+	elemType := bv.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	switch elemType.Kind() {
+	case reflect.Struct:
+		if err := r.structColumns(elemType, &SQLParsed{ID: "bulk", opt: opt}); err != nil {
+			return nil, err
+		}
+	case reflect.Map:
+		if elemType.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported bean type %v", elemType)
+		}
+
+		r.mapColumns()
+	default:
+		return nil, fmt.Errorf("unsupported bean type %v", elemType)
+	}
+
+	return r, nil
+}
+
+// structColumns maps the fields to the columns, the values are converted by the bindField of the parsed.
+func (r *tsvRows) structColumns(t reflect.Type, p *SQLParsed) error {
+	var fields []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" /* not exportable */ || f.Tag.Get("name") == "-" {
+			continue
+		}
+
+		name := f.Tag.Get("name")
+		if name == "" {
+			name = strcase.ToSnake(f.Name)
+		}
+
+		if parseEncryptTag(f) != nil && p.opt.KeyProvider == nil {
+			return fmt.Errorf("field %s error %w", f.Name, ErrNoKeyProvider)
+		}
+
+		r.columns = append(r.columns, name)
+		fields = append(fields, f)
+	}
+
+	r.values = func(bean reflect.Value) ([]interface{}, error) {
+		values := make([]interface{}, len(fields))
+
+		for i := range fields {
+			v, err := p.bindField(bean.FieldByIndex(fields[i].Index), &fields[i])
+			if err != nil {
+				return nil, err
+			}
+
+			values[i] = v
+		}
+
+		return values, nil
+	}
+
+	return nil
+}
+
+// mapColumns peeks the first map for the columns, and puts it back to the next.
+// The later maps with the keys absent in the first map are rejected.
+// nolint:goerr113
+func (r *tsvRows) mapColumns() {
+	first, ok := r.next()
+	if !ok {
+		return
+	}
+
+	first = indirectValue(first)
+	if first.IsValid() {
+		for _, k := range first.MapKeys() {
+			r.columns = append(r.columns, k.String())
+		}
+	}
+
+	sort.Strings(r.columns)
+
+	next, peeked := r.next, true
+	r.next = func() (reflect.Value, bool) {
+		if peeked {
+			peeked = false
+			return first, true
+		}
+
+		return next()
+	}
+
+	columns := make(map[string]bool, len(r.columns))
+	for _, col := range r.columns {
+		columns[col] = true
+	}
+
+	r.values = func(bean reflect.Value) ([]interface{}, error) {
+		for _, k := range bean.MapKeys() {
+			if !columns[k.String()] {
+				return nil, fmt.Errorf("column %s is absent in the first map of the columns %v", k.String(), r.columns)
+			}
+		}
+
+		values := make([]interface{}, len(r.columns))
+
+		for i, col := range r.columns {
+			v, err := bindValue(bean.MapIndex(reflect.ValueOf(col).Convert(bean.Type().Key())), false)
+			if err != nil {
+				return nil, err
+			}
+
+			values[i] = v
+		}
+
+		return values, nil
+	}
+}
+
+func (r *tsvRows) quotedColumns() []string {
+	quoted := make([]string, len(r.columns))
+	for i, col := range r.columns {
+		quoted[i] = quoteName(col)
+	}
+
+	return quoted
+}
+
+// quoteTable quotes the table name, which can be qualified by the schema like db.person.
+func quoteTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = quoteName(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// quoteName quotes the name by the backticks, with the backticks in it doubled.
+func quoteName(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func (r *tsvRows) write(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for {
+		bean, ok := r.next()
+		if !ok {
+			break
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if bean = indirectValue(bean); !bean.IsValid() {
+			continue
+		}
+
+		values, err := r.values(bean)
+		if err != nil {
+			return err
+		}
+
+		for i, v := range values {
+			if i > 0 {
+				_ = bw.WriteByte('\t')
+			}
+
+			field, err := tsvField(v)
+			if err != nil {
+				return err
+			}
+
+			_, _ = bw.WriteString(field)
+		}
+
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// nolint:gochecknoglobals
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", `\0`)
+
+// tsvField formats the value to the TSV field of LOAD DATA, \N for NULL.
+func tsvField(v interface{}) (string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+
+		v = dv
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv = indirectValue(rv); !rv.IsValid() {
+			return `\N`, nil
+		}
+
+		return tsvField(rv.Interface())
+	}
+
+	switch vv := v.(type) {
+	case nil:
+		return `\N`, nil
+	case string:
+		return tsvEscaper.Replace(vv), nil
+	case []byte:
+		if vv == nil {
+			return `\N`, nil
+		}
+
+		return tsvEscaper.Replace(string(vv)), nil
+	case bool:
+		if vv {
+			return "1", nil
+		}
+
+		return "0", nil
+	case time.Time:
+		return vv.Format("2006-01-02 15:04:05.999999"), nil
+	case float32:
+		return strconv.FormatFloat(float64(vv), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64), nil
+	default:
+		return tsvEscaper.Replace(fmt.Sprintf("%v", v)), nil
+	}
+}
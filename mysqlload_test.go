@@ -0,0 +1,137 @@
+package sqlx_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/bingoohuang/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type loadBean struct {
+	ID      int
+	Name    string `name:"nick"`
+	Remark  *string
+	Born    time.Time
+	Score   sql.NullFloat64
+	Tags    []string `sqlx:"json"`
+	Ignored string   `name:"-"`
+}
+
+func TestWriteTSV(t *testing.T) {
+	that := assert.New(t)
+
+	remark := "tab\there\nnew line \\ back"
+	born := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	beans := []loadBean{
+		{ID: 1, Name: "bingoo", Remark: &remark, Born: born, Score: sql.NullFloat64{Float64: 9.5, Valid: true},
+			Tags: []string{"a"}},
+		{ID: 2, Name: "huang", Born: born},
+	}
+
+	var buf bytes.Buffer
+
+	columns, err := sqlx.WriteTSV(context.Background(), &buf, beans)
+	that.Nil(err)
+	that.Equal([]string{"id", "nick", "remark", "born", "score", "tags"}, columns)
+	that.Equal("1\tbingoo\ttab\\there\\nnew line \\\\ back\t2020-01-02 03:04:05\t9.5\t[\"a\"]\n"+
+		"2\thuang\t\\N\t2020-01-02 03:04:05\t\\N\t\\N\n", buf.String())
+
+	ch := make(chan map[string]interface{}, 2)
+	ch <- map[string]interface{}{"id": 1, "name": "a"}
+	ch <- map[string]interface{}{"id": 2}
+	close(ch)
+
+	buf.Reset()
+
+	columns, err = sqlx.WriteTSV(context.Background(), &buf, ch)
+	that.Nil(err)
+	that.Equal([]string{"id", "name"}, columns)
+	that.Equal("1\ta\n2\t\\N\n", buf.String())
+
+	ch = make(chan map[string]interface{}, 2)
+	ch <- map[string]interface{}{"id": 1}
+	ch <- map[string]interface{}{"id": 2, "name": "b"}
+	close(ch)
+
+	_, err = sqlx.WriteTSV(context.Background(), &buf, ch)
+	that.NotNil(err, "the name is absent in the first map")
+}
+
+type loadSecret struct {
+	ID    int
+	Phone string `encrypt:"phone,deterministic"`
+}
+
+func TestWriteTSVEncrypt(t *testing.T) {
+	that := assert.New(t)
+
+	var buf bytes.Buffer
+
+	beans := []loadSecret{{ID: 1, Phone: "13800138000"}}
+
+	_, err := sqlx.WriteTSV(context.Background(), &buf, beans)
+	that.True(errors.Is(err, sqlx.ErrNoKeyProvider), "the encrypt field is never written in plain text")
+	that.Equal("", buf.String())
+
+	key := []byte("0123456789abcdef")
+	keys := sqlx.KeyProviderFn(func(string) ([]byte, error) { return key, nil })
+
+	columns, err := sqlx.WriteTSV(context.Background(), &buf, beans, sqlx.WithKeyProvider(keys))
+	that.Nil(err)
+	that.Equal([]string{"id", "phone"}, columns)
+	that.Equal("1\t"+deterministicCipher(key, "13800138000")+"\n", buf.String())
+}
+
+func TestBulkLoad(t *testing.T) {
+	that := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	that.Nil(err)
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INTO TABLE `app`.`person` CHARACTER SET utf8mb4 " +
+		`FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (` + "`id`, `age`)")).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SHOW WARNINGS").WillReturnRows(sqlmock.NewRows([]string{"Level", "Code", "Message"}).
+		AddRow("Warning", 1265, "Data truncated for column 'age' at row 2"))
+	mock.ExpectCommit()
+
+	result, err := sqlx.BulkLoad(context.Background(), db, "app.person", []person{{"100", 10}, {"200", 20}})
+	that.Nil(err)
+	that.Equal(&sqlx.BulkLoadResult{
+		RowsAffected: 2,
+		Warnings:     []sqlx.BulkLoadWarning{{"Warning", 1265, "Data truncated for column 'age' at row 2"}},
+	}, result)
+	that.Nil(mock.ExpectationsWereMet())
+
+	// the failure of the serializing mid-stream rolls back the loaded rows.
+	mock.ExpectBegin()
+	mock.ExpectExec("LOAD DATA LOCAL INFILE").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	errBad := errors.New("bad value")
+
+	_, err = sqlx.BulkLoad(context.Background(), db, "person",
+		[]loadValuer{{"100", loadValue{}}, {"200", loadValue{errBad}}})
+	that.True(errors.Is(err, errBad))
+	that.Nil(mock.ExpectationsWereMet())
+}
+
+type loadValue struct{ err error }
+
+func (v loadValue) Value() (driver.Value, error) { return "v", v.err }
+
+type loadValuer struct {
+	ID    string
+	Value loadValue
+}
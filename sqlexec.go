@@ -47,10 +47,10 @@ func ExecSQL(db SQLExec, sqlStr string, option ExecOption) ExecResult {
 	return execNonQuery(db, sqlStr, firstKey)
 }
 
-func processQuery(db SQLExec, sqlStr string, firstKey string, option ExecOption) ExecResult {
+func processQuery(db SQLExec, sqlStr string, firstKey string, option ExecOption, args ...interface{}) ExecResult {
 	start := time.Now()
 
-	rows, err := db.Query(sqlStr)
+	rows, err := db.Query(sqlStr, args...)
 	if err != nil || rows != nil && rows.Err() != nil {
 		if err == nil {
 			err = rows.Err()